@@ -17,39 +17,630 @@ package assert
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gcb-catalog-testing-bot/catalog-infra/pkg/resourcemanager"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// stepSkippedReason is the termination reason Tekton reports for a skipped step
+	stepSkippedReason = "Skipped"
 )
 
 // AssertStepResultNotEmpty asserts that a step result in the Tekton TaskRun is not empty
-func AssertStepResultNotEmpty(t *testing.T, tektonClient *versioned.Clientset, tektonRun resourcemanager.TektonRun, resultName, namespace string) {
+func AssertStepResultNotEmpty(t *testing.T, tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, resultName, namespace string) {
+	t.Helper()
+	if err := CheckStepResultNotEmpty(tektonClient, tektonRun, resultName, namespace); err != nil {
+		fail(t, nil, tektonClient, tektonRun, namespace, err)
+	}
+}
+
+// CheckStepResultNotEmpty checks that a step result in the Tekton TaskRun is not empty
+func CheckStepResultNotEmpty(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, resultName, namespace string) error {
+	steps, err := getTaskRunSteps(tektonClient, tektonRun, namespace)
+	if err != nil {
+		return err
+	}
+	return checkStepResults(steps, resultName)
+}
+
+// AssertStepResultEquals asserts that a step result in the Tekton TaskRun equals the expected value.
+// Array and object results are compared against the JSON encoding in expected.
+func AssertStepResultEquals(t *testing.T, tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, stepName, resultName, expected, namespace string) {
+	t.Helper()
+	if err := CheckStepResultEquals(tektonClient, tektonRun, stepName, resultName, expected, namespace); err != nil {
+		fail(t, nil, tektonClient, tektonRun, namespace, err)
+	}
+}
+
+// CheckStepResultEquals checks that a step result in the Tekton TaskRun equals the expected value.
+// Array and object results are compared against the JSON encoding in expected.
+func CheckStepResultEquals(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, stepName, resultName, expected, namespace string) error {
+	steps, err := getTaskRunSteps(tektonClient, tektonRun, namespace)
+	if err != nil {
+		return err
+	}
+	result, err := findStepResult(steps, stepName, resultName)
+	if err != nil {
+		return err
+	}
+	return compareResultValue(fmt.Sprintf("Step result '%s' in step '%s'", resultName, stepName), result.Value, expected)
+}
+
+// AssertStepResultMatchesRegex asserts that a string step result in the Tekton TaskRun matches the regular expression pattern
+func AssertStepResultMatchesRegex(t *testing.T, tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, stepName, resultName, pattern, namespace string) {
+	t.Helper()
+	if err := CheckStepResultMatchesRegex(tektonClient, tektonRun, stepName, resultName, pattern, namespace); err != nil {
+		fail(t, nil, tektonClient, tektonRun, namespace, err)
+	}
+}
+
+// CheckStepResultMatchesRegex checks that a string step result in the Tekton TaskRun matches the regular expression pattern
+func CheckStepResultMatchesRegex(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, stepName, resultName, pattern, namespace string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern '%s': %v", pattern, err)
+	}
+	steps, err := getTaskRunSteps(tektonClient, tektonRun, namespace)
+	if err != nil {
+		return err
+	}
+	result, err := findStepResult(steps, stepName, resultName)
+	if err != nil {
+		return err
+	}
+	switch result.Value.Type {
+	case v1.ParamTypeString:
+		if !re.MatchString(result.Value.StringVal) {
+			return fmt.Errorf("Step result '%s' in step '%s' does not match %q\n got: %q", resultName, stepName, pattern, result.Value.StringVal)
+		}
+	case v1.ParamTypeArray, v1.ParamTypeObject:
+		return fmt.Errorf("Step result '%s' in step '%s' is of type %s, only string results can be matched against a pattern", resultName, stepName, result.Value.Type)
+	default:
+		return fmt.Errorf("unsupported result type for '%s': %v", resultName, result.Value.Type)
+	}
+	return nil
+}
+
+// AssertStepObjectResult asserts that an object step result in the Tekton TaskRun has exactly the expected keys and values
+func AssertStepObjectResult(t *testing.T, tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, stepName, resultName string, expected map[string]string, namespace string) {
+	t.Helper()
+	if err := CheckStepObjectResult(tektonClient, tektonRun, stepName, resultName, expected, namespace); err != nil {
+		fail(t, nil, tektonClient, tektonRun, namespace, err)
+	}
+}
+
+// CheckStepObjectResult checks that an object step result in the Tekton TaskRun has exactly the expected keys and values
+func CheckStepObjectResult(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, stepName, resultName string, expected map[string]string, namespace string) error {
+	steps, err := getTaskRunSteps(tektonClient, tektonRun, namespace)
+	if err != nil {
+		return err
+	}
+	result, err := findStepResult(steps, stepName, resultName)
+	if err != nil {
+		return err
+	}
+	if result.Value.Type != v1.ParamTypeObject {
+		return fmt.Errorf("Step result '%s' in step '%s' is of type %s, not object", resultName, stepName, result.Value.Type)
+	}
+
+	var diffs []string
+	keys := make([]string, 0, len(expected)+len(result.Value.ObjectVal))
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	for key := range result.Value.ObjectVal {
+		if _, ok := expected[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		want, wantOK := expected[key]
+		got, gotOK := result.Value.ObjectVal[key]
+		switch {
+		case !gotOK:
+			diffs = append(diffs, fmt.Sprintf("  missing key %q (want %q)", key, want))
+		case !wantOK:
+			diffs = append(diffs, fmt.Sprintf("  unexpected key %q (got %q)", key, got))
+		case got != want:
+			diffs = append(diffs, fmt.Sprintf("  key %q: got %q, want %q", key, got, want))
+		}
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("Step result '%s' in step '%s' does not match:\n%s", resultName, stepName, strings.Join(diffs, "\n"))
+	}
+	return nil
+}
+
+// AssertStepArrayResult asserts that an array step result in the Tekton TaskRun has exactly the expected elements, in order
+func AssertStepArrayResult(t *testing.T, tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, stepName, resultName string, expected []string, namespace string) {
+	t.Helper()
+	if err := CheckStepArrayResult(tektonClient, tektonRun, stepName, resultName, expected, namespace); err != nil {
+		fail(t, nil, tektonClient, tektonRun, namespace, err)
+	}
+}
+
+// CheckStepArrayResult checks that an array step result in the Tekton TaskRun has exactly the expected elements, in order
+func CheckStepArrayResult(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, stepName, resultName string, expected []string, namespace string) error {
+	steps, err := getTaskRunSteps(tektonClient, tektonRun, namespace)
+	if err != nil {
+		return err
+	}
+	result, err := findStepResult(steps, stepName, resultName)
+	if err != nil {
+		return err
+	}
+	if result.Value.Type != v1.ParamTypeArray {
+		return fmt.Errorf("Step result '%s' in step '%s' is of type %s, not array", resultName, stepName, result.Value.Type)
+	}
+
+	got := result.Value.ArrayVal
+	var diffs []string
+	for i := 0; i < len(got) || i < len(expected); i++ {
+		switch {
+		case i >= len(got):
+			diffs = append(diffs, fmt.Sprintf("  [%d]: missing (want %q)", i, expected[i]))
+		case i >= len(expected):
+			diffs = append(diffs, fmt.Sprintf("  [%d]: unexpected %q", i, got[i]))
+		case got[i] != expected[i]:
+			diffs = append(diffs, fmt.Sprintf("  [%d]: got %q, want %q", i, got[i], expected[i]))
+		}
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("Step result '%s' in step '%s' does not match:\n%s", resultName, stepName, strings.Join(diffs, "\n"))
+	}
+	return nil
+}
+
+// AssertStepExitCode asserts that a step in the Tekton TaskRun terminated with the expected exit code
+func AssertStepExitCode(t *testing.T, tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, stepName string, expected int, namespace string) {
+	t.Helper()
+	if err := CheckStepExitCode(tektonClient, tektonRun, stepName, expected, namespace); err != nil {
+		fail(t, nil, tektonClient, tektonRun, namespace, err)
+	}
+}
+
+// CheckStepExitCode checks that a step in the Tekton TaskRun terminated with the expected exit code
+func CheckStepExitCode(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, stepName string, expected int, namespace string) error {
+	steps, err := getTaskRunSteps(tektonClient, tektonRun, namespace)
+	if err != nil {
+		return err
+	}
+	step, err := findStep(steps, stepName)
+	if err != nil {
+		return err
+	}
+	if step.Terminated == nil {
+		return fmt.Errorf("Step '%s' has not terminated", stepName)
+	}
+	if int(step.Terminated.ExitCode) != expected {
+		return fmt.Errorf("Step '%s' exited with code %d, want %d", stepName, step.Terminated.ExitCode, expected)
+	}
+	return nil
+}
+
+// AssertAllStepsSucceeded asserts that every step in the Tekton TaskRun terminated with exit code 0, reporting all failing steps at once
+func AssertAllStepsSucceeded(t *testing.T, tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, namespace string) {
+	t.Helper()
+	if err := CheckAllStepsSucceeded(tektonClient, tektonRun, namespace); err != nil {
+		fail(t, nil, tektonClient, tektonRun, namespace, err)
+	}
+}
+
+// CheckAllStepsSucceeded checks that every step in the Tekton TaskRun terminated with exit code 0, reporting all failing steps at once
+func CheckAllStepsSucceeded(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, namespace string) error {
+	steps, err := getTaskRunSteps(tektonClient, tektonRun, namespace)
+	if err != nil {
+		return err
+	}
+	// A TaskRun that failed validation or whose pod never started has no step states
+	if len(steps) == 0 {
+		return fmt.Errorf("TaskRun '%s' has no step states, its pod may not have started", tektonRun.Name)
+	}
+	var failed []string
+	for _, step := range steps {
+		switch {
+		case step.Terminated == nil:
+			failed = append(failed, fmt.Sprintf("  step '%s' did not terminate", step.Name))
+		case step.Terminated.ExitCode != 0:
+			failed = append(failed, fmt.Sprintf("  step '%s' exited with code %d (%s)", step.Name, step.Terminated.ExitCode, step.Terminated.Reason))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d steps in TaskRun '%s' did not succeed:\n%s", len(failed), len(steps), tektonRun.Name, strings.Join(failed, "\n"))
+	}
+	return nil
+}
+
+// AssertNumberOfSteps asserts that the Tekton TaskRun ran the expected number of steps
+func AssertNumberOfSteps(t *testing.T, tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, expected int, namespace string) {
+	t.Helper()
+	if err := CheckNumberOfSteps(tektonClient, tektonRun, expected, namespace); err != nil {
+		fail(t, nil, tektonClient, tektonRun, namespace, err)
+	}
+}
+
+// CheckNumberOfSteps checks that the Tekton TaskRun ran the expected number of steps
+func CheckNumberOfSteps(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, expected int, namespace string) error {
+	steps, err := getTaskRunSteps(tektonClient, tektonRun, namespace)
+	if err != nil {
+		return err
+	}
+	if len(steps) != expected {
+		names := make([]string, 0, len(steps))
+		for _, step := range steps {
+			names = append(names, step.Name)
+		}
+		return fmt.Errorf("TaskRun '%s' has %d steps, want %d (steps: %s)", tektonRun.Name, len(steps), expected, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// AssertTaskRunFailedWithReason asserts that the Tekton TaskRun failed, i.e. its Succeeded condition is False, with the expected reason
+func AssertTaskRunFailedWithReason(t *testing.T, tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, expectedReason, namespace string) {
+	t.Helper()
+	if err := CheckTaskRunFailedWithReason(tektonClient, tektonRun, expectedReason, namespace); err != nil {
+		fail(t, nil, tektonClient, tektonRun, namespace, err)
+	}
+}
+
+// CheckTaskRunFailedWithReason checks that the Tekton TaskRun failed, i.e. its Succeeded condition is False, with the expected reason
+func CheckTaskRunFailedWithReason(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, expectedReason, namespace string) error {
+	if strings.ToLower(tektonRun.Kind) != "taskrun" {
+		return fmt.Errorf("unsupported Tekton Run kind for verifying TaskRun failure: %s", tektonRun.Kind)
+	}
+	cond, err := resourcemanager.GetTektonRunCondition(context.TODO(), tektonClient, tektonRun, namespace)
+	if err != nil {
+		return err
+	}
+	if cond.Status != corev1.ConditionFalse {
+		return fmt.Errorf("TaskRun '%s' did not fail: Succeeded=%s, reason: %s, message: %s", tektonRun.Name, cond.Status, cond.Reason, cond.Message)
+	}
+	if cond.Reason != expectedReason {
+		return fmt.Errorf("TaskRun '%s' failed with reason '%s', want '%s' (message: %s)", tektonRun.Name, cond.Reason, expectedReason, cond.Message)
+	}
+	return nil
+}
+
+// AssertTaskRunCompletedWithin asserts that the Tekton TaskRun completed and took at most maxDuration from start to completion
+func AssertTaskRunCompletedWithin(t *testing.T, tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, maxDuration time.Duration, namespace string) {
+	t.Helper()
+	if err := CheckTaskRunCompletedWithin(tektonClient, tektonRun, maxDuration, namespace); err != nil {
+		fail(t, nil, tektonClient, tektonRun, namespace, err)
+	}
+}
+
+// CheckTaskRunCompletedWithin checks that the Tekton TaskRun completed and took at most maxDuration from start to completion
+func CheckTaskRunCompletedWithin(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, maxDuration time.Duration, namespace string) error {
+	if strings.ToLower(tektonRun.Kind) != "taskrun" {
+		return fmt.Errorf("unsupported Tekton Run kind for verifying TaskRun duration: %s", tektonRun.Kind)
+	}
+	taskRun, err := tektonClient.TektonV1().TaskRuns(namespace).Get(context.TODO(), tektonRun.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get TaskRun: %v", err)
+	}
+	if taskRun.Status.StartTime == nil {
+		return fmt.Errorf("TaskRun '%s' has not started", tektonRun.Name)
+	}
+	if taskRun.Status.CompletionTime == nil {
+		return fmt.Errorf("TaskRun '%s' has not completed, running for %v", tektonRun.Name, time.Since(taskRun.Status.StartTime.Time).Round(time.Second))
+	}
+	duration := taskRun.Status.CompletionTime.Sub(taskRun.Status.StartTime.Time)
+	if duration > maxDuration {
+		return fmt.Errorf("TaskRun '%s' took %v, want at most %v", tektonRun.Name, duration, maxDuration)
+	}
+	return nil
+}
+
+// AssertStepSkipped asserts that a step in the Tekton TaskRun was skipped
+func AssertStepSkipped(t *testing.T, tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, stepName, namespace string) {
+	t.Helper()
+	if err := CheckStepSkipped(tektonClient, tektonRun, stepName, namespace); err != nil {
+		fail(t, nil, tektonClient, tektonRun, namespace, err)
+	}
+}
+
+// CheckStepSkipped checks that a step in the Tekton TaskRun was skipped
+func CheckStepSkipped(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, stepName, namespace string) error {
+	steps, err := getTaskRunSteps(tektonClient, tektonRun, namespace)
+	if err != nil {
+		return err
+	}
+	step, err := findStep(steps, stepName)
+	if err != nil {
+		return err
+	}
+	if step.TerminationReason == stepSkippedReason || (step.Terminated != nil && step.Terminated.Reason == stepSkippedReason) {
+		return nil
+	}
+	return fmt.Errorf("Step '%s' was not skipped (termination reason: '%s')", stepName, step.TerminationReason)
+}
+
+// AssertTaskSkipped asserts that a pipeline task in the Tekton PipelineRun was skipped
+func AssertTaskSkipped(t *testing.T, tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, taskName, namespace string) {
+	t.Helper()
+	if err := CheckTaskSkipped(tektonClient, tektonRun, taskName, namespace); err != nil {
+		fail(t, nil, tektonClient, tektonRun, namespace, err)
+	}
+}
+
+// CheckTaskSkipped checks that a pipeline task in the Tekton PipelineRun was skipped
+func CheckTaskSkipped(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, taskName, namespace string) error {
+	if strings.ToLower(tektonRun.Kind) != "pipelinerun" {
+		return fmt.Errorf("unsupported Tekton Run kind for verifying skipped tasks: %s", tektonRun.Kind)
+	}
+	pipelineRun, err := tektonClient.TektonV1().PipelineRuns(namespace).Get(context.TODO(), tektonRun.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get PipelineRun: %v", err)
+	}
+	for _, skipped := range pipelineRun.Status.SkippedTasks {
+		if skipped.Name == taskName {
+			return nil
+		}
+	}
+	if spec := pipelineRun.Status.PipelineSpec; spec != nil {
+		for _, task := range append(spec.Tasks, spec.Finally...) {
+			if task.Name == taskName {
+				return fmt.Errorf("Task '%s' was not skipped", taskName)
+			}
+		}
+	}
+	return fmt.Errorf("Task '%s' not found in PipelineRun '%s'", taskName, tektonRun.Name)
+}
+
+// AssertPipelineTaskRan asserts that a pipeline task in the Tekton PipelineRun ran, i.e. its child run was created and reported a Succeeded condition
+func AssertPipelineTaskRan(t *testing.T, tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, pipelineTaskName, namespace string) {
+	t.Helper()
+	if err := CheckPipelineTaskRan(tektonClient, tektonRun, pipelineTaskName, namespace); err != nil {
+		fail(t, nil, tektonClient, tektonRun, namespace, err)
+	}
+}
+
+// CheckPipelineTaskRan checks that a pipeline task in the Tekton PipelineRun ran, i.e. its child run was created and reported a Succeeded condition
+func CheckPipelineTaskRan(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, pipelineTaskName, namespace string) error {
+	if strings.ToLower(tektonRun.Kind) != "pipelinerun" {
+		return fmt.Errorf("unsupported Tekton Run kind for verifying pipeline tasks: %s", tektonRun.Kind)
+	}
+	pipelineRun, err := tektonClient.TektonV1().PipelineRuns(namespace).Get(context.TODO(), tektonRun.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get PipelineRun: %v", err)
+	}
+
+	var observed []string
+	for _, child := range pipelineRun.Status.ChildReferences {
+		if child.PipelineTaskName != pipelineTaskName {
+			observed = append(observed, child.PipelineTaskName)
+			continue
+		}
+		childRun := resourcemanager.TektonRun{Name: child.Name, Kind: child.Kind}
+		cond, err := resourcemanager.GetTektonRunCondition(context.TODO(), tektonClient, childRun, namespace)
+		if err != nil {
+			return err
+		}
+		// A run without the condition is reported as Unknown without a reason
+		if cond.Reason == "" && cond.Status == corev1.ConditionUnknown {
+			return fmt.Errorf("Task '%s' (%s %s) has not reported a Succeeded condition", pipelineTaskName, child.Kind, child.Name)
+		}
+		return nil
+	}
+	for _, skipped := range pipelineRun.Status.SkippedTasks {
+		if skipped.Name == pipelineTaskName {
+			return fmt.Errorf("Task '%s' was skipped: %s", pipelineTaskName, skipped.Reason)
+		}
+	}
+	return fmt.Errorf("Task '%s' did not run in PipelineRun '%s', observed child tasks: [%s]", pipelineTaskName, tektonRun.Name, strings.Join(observed, ", "))
+}
+
+// AssertStepLogsContain asserts that the logs of a step in the Tekton TaskRun contain the substring
+func AssertStepLogsContain(t *testing.T, kubeClient kubernetes.Interface, tektonRun resourcemanager.TektonRun, stepName, substring, namespace string) {
+	t.Helper()
+	if err := CheckStepLogsContain(kubeClient, tektonRun, stepName, substring, namespace); err != nil {
+		fail(t, kubeClient, nil, tektonRun, namespace, err)
+	}
+}
+
+// CheckStepLogsContain checks that the logs of a step in the Tekton TaskRun contain the substring
+func CheckStepLogsContain(kubeClient kubernetes.Interface, tektonRun resourcemanager.TektonRun, stepName, substring, namespace string) error {
+	logs, err := getStepLogs(kubeClient, tektonRun, stepName, namespace)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(logs, substring) {
+		return fmt.Errorf("logs of step '%s' do not contain %q\nlogs:\n%s", stepName, substring, logs)
+	}
+	return nil
+}
+
+// AssertStepLogsMatch asserts that the logs of a step in the Tekton TaskRun match the regular expression pattern
+func AssertStepLogsMatch(t *testing.T, kubeClient kubernetes.Interface, tektonRun resourcemanager.TektonRun, stepName, pattern, namespace string) {
+	t.Helper()
+	if err := CheckStepLogsMatch(kubeClient, tektonRun, stepName, pattern, namespace); err != nil {
+		fail(t, kubeClient, nil, tektonRun, namespace, err)
+	}
+}
+
+// CheckStepLogsMatch checks that the logs of a step in the Tekton TaskRun match the regular expression pattern
+func CheckStepLogsMatch(kubeClient kubernetes.Interface, tektonRun resourcemanager.TektonRun, stepName, pattern, namespace string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern '%s': %v", pattern, err)
+	}
+	logs, err := getStepLogs(kubeClient, tektonRun, stepName, namespace)
+	if err != nil {
+		return err
+	}
+	if !re.MatchString(logs) {
+		return fmt.Errorf("logs of step '%s' do not match %q\nlogs:\n%s", stepName, pattern, logs)
+	}
+	return nil
+}
+
+// AssertPipelineResultNotEmpty asserts that a pipeline-level result in the Tekton PipelineRun is not empty
+func AssertPipelineResultNotEmpty(t *testing.T, tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, resultName, namespace string) {
 	t.Helper()
-	var steps []v1.StepState
+	if err := CheckPipelineResultNotEmpty(tektonClient, tektonRun, resultName, namespace); err != nil {
+		fail(t, nil, tektonClient, tektonRun, namespace, err)
+	}
+}
 
+// CheckPipelineResultNotEmpty checks that a pipeline-level result in the Tekton PipelineRun is not empty
+func CheckPipelineResultNotEmpty(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, resultName, namespace string) error {
+	result, err := findPipelineResult(tektonClient, tektonRun, resultName, namespace)
+	if err != nil {
+		return err
+	}
+	switch result.Value.Type {
+	case v1.ParamTypeString:
+		if result.Value.StringVal != "" {
+			return nil
+		}
+	case v1.ParamTypeArray:
+		if len(result.Value.ArrayVal) > 0 {
+			return nil
+		}
+	case v1.ParamTypeObject:
+		if len(result.Value.ObjectVal) > 0 {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported result type for '%s': %v", resultName, result.Value.Type)
+	}
+	return fmt.Errorf("Pipeline result '%s' is empty", resultName)
+}
+
+// AssertPipelineResultEquals asserts that a pipeline-level result in the Tekton PipelineRun equals the expected value.
+// Array and object results are compared against the JSON encoding in expected.
+func AssertPipelineResultEquals(t *testing.T, tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, resultName, expected, namespace string) {
+	t.Helper()
+	if err := CheckPipelineResultEquals(tektonClient, tektonRun, resultName, expected, namespace); err != nil {
+		fail(t, nil, tektonClient, tektonRun, namespace, err)
+	}
+}
+
+// CheckPipelineResultEquals checks that a pipeline-level result in the Tekton PipelineRun equals the expected value.
+// Array and object results are compared against the JSON encoding in expected.
+func CheckPipelineResultEquals(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, resultName, expected, namespace string) error {
+	result, err := findPipelineResult(tektonClient, tektonRun, resultName, namespace)
+	if err != nil {
+		return err
+	}
+	return compareResultValue(fmt.Sprintf("Pipeline result '%s'", resultName), result.Value, expected)
+}
+
+// fail fails the test with err, with sensitive values like tokens in step logs redacted,
+// first dumping the run to CATALOG_ARTIFACT_DIR if it is set with whichever of the clients the assertion has
+func fail(t *testing.T, kubeClient kubernetes.Interface, tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, namespace string, err error) {
+	t.Helper()
+	resourcemanager.DumpTektonRunArtifactsWithClient(t, kubeClient, tektonClient, tektonRun, namespace)
+	resourcemanager.Fatalf(t, "%v", err)
+}
+
+// getStepLogs gets the logs of the step container in the Tekton TaskRun pod
+func getStepLogs(kubeClient kubernetes.Interface, tektonRun resourcemanager.TektonRun, stepName, namespace string) (string, error) {
+	if strings.ToLower(tektonRun.Kind) != "taskrun" {
+		return "", fmt.Errorf("unsupported Tekton Run kind for verifying step logs: %s", tektonRun.Kind)
+	}
+	logs, err := resourcemanager.GetStepLogs(context.TODO(), kubeClient, tektonRun, stepName, namespace)
+	if err != nil {
+		return "", fmt.Errorf("failed to get logs of step '%s': %v", stepName, err)
+	}
+	return logs, nil
+}
+
+// findPipelineResult gets the Tekton PipelineRun and finds the named pipeline-level result
+func findPipelineResult(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, resultName, namespace string) (v1.PipelineRunResult, error) {
+	if strings.ToLower(tektonRun.Kind) != "pipelinerun" {
+		return v1.PipelineRunResult{}, fmt.Errorf("unsupported Tekton Run kind for verifying pipeline-level results: %s", tektonRun.Kind)
+	}
+	results, err := resourcemanager.GetPipelineRunResults(context.TODO(), tektonClient, tektonRun.Name, namespace)
+	if err != nil {
+		return v1.PipelineRunResult{}, err
+	}
+	for _, result := range results {
+		if result.Name == resultName {
+			return result, nil
+		}
+	}
+	return v1.PipelineRunResult{}, fmt.Errorf("Pipeline result '%s' not found", resultName)
+}
+
+// compareResultValue compares a result value to the expected value, JSON-encoded for array and object results
+func compareResultValue(description string, value v1.ResultValue, expected string) error {
+	switch value.Type {
+	case v1.ParamTypeString:
+		if value.StringVal != expected {
+			return fmt.Errorf("%s does not match\n got: %q\nwant: %q", description, value.StringVal, expected)
+		}
+	case v1.ParamTypeArray, v1.ParamTypeObject:
+		var want v1.ResultValue
+		if err := json.Unmarshal([]byte(expected), &want); err != nil {
+			return fmt.Errorf("expected value for %s is not valid JSON: %v", description, err)
+		}
+		if !reflect.DeepEqual(value.ArrayVal, want.ArrayVal) || !reflect.DeepEqual(value.ObjectVal, want.ObjectVal) {
+			got, _ := json.Marshal(value)
+			return fmt.Errorf("%s does not match\n got: %s\nwant: %s", description, got, expected)
+		}
+	default:
+		return fmt.Errorf("unsupported result type for %s: %v", description, value.Type)
+	}
+	return nil
+}
+
+// getTaskRunSteps gets the step states of the Tekton TaskRun
+func getTaskRunSteps(tektonClient versioned.Interface, tektonRun resourcemanager.TektonRun, namespace string) ([]v1.StepState, error) {
 	switch strings.ToLower(tektonRun.Kind) {
 	case "taskrun":
 		taskRun, err := tektonClient.TektonV1().TaskRuns(namespace).Get(context.TODO(), tektonRun.Name, metav1.GetOptions{})
 		if err != nil {
-			t.Fatalf("failed to get TaskRun: %v", err)
+			return nil, fmt.Errorf("failed to get TaskRun: %v", err)
 		}
-		steps = taskRun.Status.Steps
+		return taskRun.Status.Steps, nil
 	case "pipelinerun":
-		t.Fatal("PipelineRun not supported for verifying step-level results")
+		return nil, fmt.Errorf("PipelineRun not supported for verifying step-level results")
 	default:
-		t.Fatalf("unsupported Tekton Run kind: %s", tektonRun.Kind)
+		return nil, fmt.Errorf("unsupported Tekton Run kind: %s", tektonRun.Kind)
 	}
+}
 
-	checkStepResults(t, steps, resultName)
+// findStepResult finds the named result of the named step
+func findStepResult(steps []v1.StepState, stepName, resultName string) (v1.TaskRunStepResult, error) {
+	step, err := findStep(steps, stepName)
+	if err != nil {
+		return v1.TaskRunStepResult{}, err
+	}
+	for _, result := range step.Results {
+		if result.Name == resultName {
+			return result, nil
+		}
+	}
+	return v1.TaskRunStepResult{}, fmt.Errorf("Step result '%s' not found in step '%s'", resultName, stepName)
+}
+
+// findStep finds the named step
+func findStep(steps []v1.StepState, stepName string) (v1.StepState, error) {
+	for _, step := range steps {
+		if step.Name == stepName {
+			return step, nil
+		}
+	}
+	return v1.StepState{}, fmt.Errorf("Step '%s' not found", stepName)
 }
 
 // checkStepResults checks that a step result in the Tekton TaskRun is not empty
-func checkStepResults(t *testing.T, steps []v1.StepState, resultName string) {
-	t.Helper()
+func checkStepResults(steps []v1.StepState, resultName string) error {
 	for _, step := range steps {
 		for _, result := range step.Results {
 			if result.Name != resultName {
@@ -58,22 +649,22 @@ func checkStepResults(t *testing.T, steps []v1.StepState, resultName string) {
 			switch result.Type {
 			case v1.ResultsTypeString:
 				if result.Value.StringVal != "" {
-					return
+					return nil
 				}
 			case v1.ResultsTypeArray:
 				if len(result.Value.ArrayVal) > 0 {
-					return
+					return nil
 				}
 			case v1.ResultsTypeObject:
 				if result.Value.ObjectVal != nil && len(result.Value.ObjectVal) > 0 {
-					return
+					return nil
 				}
 			default:
-				t.Fatalf("unsupported result type for '%s': %v", resultName, result.Type)
+				return fmt.Errorf("unsupported result type for '%s': %v", resultName, result.Type)
 			}
 
-			t.Fatalf("Step result '%s' in step '%s' is empty", resultName, step.Name)
+			return fmt.Errorf("Step result '%s' in step '%s' is empty", resultName, step.Name)
 		}
 	}
-	t.Fatalf("Step result '%s' not found in any step", resultName)
+	return fmt.Errorf("Step result '%s' not found in any step", resultName)
 }
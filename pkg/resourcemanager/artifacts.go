@@ -0,0 +1,164 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemanager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// artifactDirEnv is the environment variable that, when set, is the directory failed runs are dumped to for post-mortem debugging
+	artifactDirEnv = "CATALOG_ARTIFACT_DIR"
+)
+
+// GetTektonRunYAML gets the full YAML of the Tekton TaskRun, PipelineRun, CustomRun or Run, including its status, with kubectl.
+// GetTektonRunYAMLWithClient gets the same without a subprocess when a Tekton clientset is available.
+func GetTektonRunYAML(ctx context.Context, tektonRun TektonRun, namespace string) (string, error) {
+	resource := strings.ToLower(tektonRun.Kind) + ".tekton.dev"
+	output, err := Runner.Run(ctx, "kubectl", "get", resource, tektonRun.Name, "-n", namespace, "-o", "yaml")
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s %s: %v\n%s", tektonRun.Kind, tektonRun.Name, err, output)
+	}
+	return string(output), nil
+}
+
+// GetTektonRunYAMLWithClient gets the full YAML of the Tekton TaskRun, PipelineRun, CustomRun or Run, including its status,
+// through the Tekton clientset rather than kubectl
+func GetTektonRunYAMLWithClient(ctx context.Context, tektonClient versioned.Interface, tektonRun TektonRun, namespace string) (string, error) {
+	var obj interface{}
+	switch strings.ToLower(tektonRun.Kind) {
+	case "taskrun":
+		taskRun, err := tektonClient.TektonV1().TaskRuns(namespace).Get(ctx, tektonRun.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get TaskRun: %v", err)
+		}
+		// The typed clientset does not fill in the type meta
+		taskRun.APIVersion, taskRun.Kind = v1.SchemeGroupVersion.String(), "TaskRun"
+		obj = taskRun
+	case "pipelinerun":
+		pipelineRun, err := tektonClient.TektonV1().PipelineRuns(namespace).Get(ctx, tektonRun.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get PipelineRun: %v", err)
+		}
+		pipelineRun.APIVersion, pipelineRun.Kind = v1.SchemeGroupVersion.String(), "PipelineRun"
+		obj = pipelineRun
+	case "customrun":
+		customRun, err := tektonClient.TektonV1beta1().CustomRuns(namespace).Get(ctx, tektonRun.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get CustomRun: %v", err)
+		}
+		customRun.APIVersion, customRun.Kind = v1beta1.SchemeGroupVersion.String(), "CustomRun"
+		obj = customRun
+	case "run":
+		run, err := tektonClient.TektonV1alpha1().Runs(namespace).Get(ctx, tektonRun.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get Run: %v", err)
+		}
+		run.APIVersion, run.Kind = v1alpha1.SchemeGroupVersion.String(), "Run"
+		obj = run
+	default:
+		return "", fmt.Errorf("unsupported Tekton Run kind: %s", tektonRun.Kind)
+	}
+
+	runYAML, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s %s: %v", tektonRun.Kind, tektonRun.Name, err)
+	}
+	return string(runYAML), nil
+}
+
+// DumpTektonRunArtifacts writes the YAML and the pod logs of the Tekton run to timestamped files under the directory in
+// CATALOG_ARTIFACT_DIR, so the state of a failed run outlives its namespace. It does nothing if CATALOG_ARTIFACT_DIR is not set.
+// Failures to collect the artifacts are logged rather than failing the test.
+func DumpTektonRunArtifacts(t *testing.T, tektonRun TektonRun, namespace string) {
+	t.Helper()
+	DumpTektonRunArtifactsWithClient(t, nil, nil, tektonRun, namespace)
+}
+
+// DumpTektonRunArtifactsWithClient dumps the artifacts of the Tekton run like DumpTektonRunArtifacts, reading the pod logs through
+// the kubernetes client and the run through the Tekton client rather than kubectl. Either client may be nil to use kubectl instead,
+// and kubectl is also used when the pod logs cannot be read with the kubernetes client.
+func DumpTektonRunArtifactsWithClient(t *testing.T, kubeClient kubernetes.Interface, tektonClient versioned.Interface, tektonRun TektonRun, namespace string) {
+	t.Helper()
+	dir := os.Getenv(artifactDirEnv)
+	if dir == "" {
+		return
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		Logf(t, "failed to create artifact directory %s: %v", dir, err)
+		return
+	}
+	ctx := context.TODO()
+	base := filepath.Join(dir, fmt.Sprintf("%s-%s-%s", time.Now().Format("20060102-150405"), strings.ToLower(tektonRun.Kind), tektonRun.Name))
+
+	var runYAML string
+	var err error
+	if tektonClient != nil {
+		runYAML, err = GetTektonRunYAMLWithClient(ctx, tektonClient, tektonRun, namespace)
+	} else {
+		runYAML, err = GetTektonRunYAML(ctx, tektonRun, namespace)
+	}
+	if err != nil {
+		Logf(t, "failed to dump %s %s: %v", tektonRun.Kind, tektonRun.Name, err)
+	} else {
+		writeArtifact(t, base+".yaml", runYAML)
+	}
+
+	// CustomRuns and Runs are executed by controllers rather than pods, so they have no logs
+	label, err := tektonRunPodLabel(tektonRun)
+	if err != nil {
+		return
+	}
+	if kubeClient != nil {
+		logs, err := GetTektonRunLogs(ctx, kubeClient, tektonRun, namespace)
+		if err == nil {
+			writeArtifact(t, base+".log", logs)
+			return
+		}
+		Logf(t, "failed to dump logs of %s %s with the kubernetes client, falling back to kubectl: %v", tektonRun.Kind, tektonRun.Name, err)
+	}
+	// kubectl logs keeps only the last 10 lines per container with a selector unless --tail is set
+	output, err := Runner.Run(ctx, "kubectl", "logs", "-n", namespace, "-l", fmt.Sprintf("%s=%s", label, tektonRun.Name),
+		"--all-containers", "--prefix", "--ignore-errors", "--tail=-1")
+	if err != nil {
+		Logf(t, "failed to dump logs of %s %s: %v\n%s", tektonRun.Kind, tektonRun.Name, err, output)
+		return
+	}
+	writeArtifact(t, base+".log", string(output))
+}
+
+// writeArtifact writes the content, with sensitive values redacted, to the artifact file and logs its path
+func writeArtifact(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(Redact(content)), 0644); err != nil {
+		Logf(t, "failed to write artifact %s: %v", path, err)
+		return
+	}
+	Logf(t, "wrote artifact %s", path)
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemanager
+
+import (
+	"errors"
+)
+
+// Sentinel errors classifying how an operation failed, to be checked with errors.Is
+var (
+	// ErrInvalidYAML is returned when a YAML file cannot be parsed or does not describe valid Tekton resources
+	ErrInvalidYAML = errors.New("invalid YAML")
+	// ErrApplyFailed is returned when applying or creating resources in the cluster fails
+	ErrApplyFailed = errors.New("apply failed")
+	// ErrNamespaceCreateFailed is returned when the test namespace cannot be created
+	ErrNamespaceCreateFailed = errors.New("namespace create failed")
+	// ErrRunTimedOut is returned when a Tekton run does not reach the awaited state within the timeout
+	ErrRunTimedOut = errors.New("run timed out")
+	// ErrRunFailed is returned when a Tekton run completes without meeting the expected condition, or cannot make progress
+	ErrRunFailed = errors.New("run failed")
+)
+
+// classifiedError is an error classified by one of the sentinel errors, keeping the message of the underlying error
+type classifiedError struct {
+	kind error
+	err  error
+}
+
+// classify returns err classified by the sentinel error kind, so errors.Is matches both kind and the errors wrapped by err
+func classify(kind, err error) error {
+	return &classifiedError{kind: kind, err: err}
+}
+
+// Error implements error
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the sentinel error and the underlying error
+func (e *classifiedError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
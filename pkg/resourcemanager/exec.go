@@ -0,0 +1,194 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemanager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcb-catalog-testing-bot/catalog-infra/pkg/retry"
+	"gopkg.in/yaml.v3"
+)
+
+// ExecRunner runs the external commands (e.g. kubectl) this package shells out to
+type ExecRunner interface {
+	// Run runs the command and returns its combined stdout and stderr
+	Run(ctx context.Context, name string, args ...string) ([]byte, error)
+	// RunWithInput runs the command with input piped to stdin and returns its combined stdout and stderr
+	RunWithInput(ctx context.Context, input []byte, name string, args ...string) ([]byte, error)
+}
+
+// requiredTools are the command line tools this package shells out to
+var requiredTools = []string{"kubectl"}
+
+// Runner is the ExecRunner used for every shell-out in this package. Tests can replace it with a fake returning canned output.
+var Runner ExecRunner = realRunner{}
+
+// RetryAttempts and RetryBackoff configure how often, and after how long a first wait, kubectl applies that fail
+// transiently (API throttling, server errors, network errors) are retried
+var (
+	RetryAttempts = 3
+	RetryBackoff  = 2 * time.Second
+)
+
+// runWithRetry runs the command with Runner, piping input to stdin if it is not nil, and retries transient failures.
+// The output of the failed attempt is part of the error checked for transient failures, so it is returned along with it.
+func runWithRetry(ctx context.Context, input []byte, name string, args ...string) ([]byte, error) {
+	var output []byte
+	err := retry.Do(ctx, RetryAttempts, RetryBackoff, func() error {
+		var err error
+		if input != nil {
+			output, err = Runner.RunWithInput(ctx, input, name, args...)
+		} else {
+			output, err = Runner.Run(ctx, name, args...)
+		}
+		if err != nil {
+			return fmt.Errorf("%v\n%s", err, output)
+		}
+		return nil
+	})
+	return output, err
+}
+
+// realRunner runs commands with os/exec
+type realRunner struct{}
+
+// Run runs the command with os/exec
+func (realRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, binaryPath(name), args...).CombinedOutput()
+}
+
+// RunWithInput runs the command with os/exec, piping input to stdin
+func (realRunner) RunWithInput(ctx context.Context, input []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, binaryPath(name), args...)
+	cmd.Stdin = bytes.NewReader(input)
+	return cmd.CombinedOutput()
+}
+
+// DryRunRunner is an ExecRunner that logs every command to the test log instead of running it, to show what a test would do
+// against the cluster. kubectl apply and create report the resources of the applied YAML as created; other commands return no output.
+// Calls made through the k8s and Tekton clientsets are not covered, except that SetupTest skips creating and deleting its namespace
+// and InitK8sClients skips checking for kubectl while dry-run is enabled. Waiting for a run fails, as no run is created.
+type DryRunRunner struct {
+	T *testing.T
+}
+
+// EnableDryRun replaces Runner with a DryRunRunner for the rest of the test and restores it when the test completes.
+// Call it before InitK8sClients and SetupTest so they do not touch the cluster either.
+// Runner is shared by the whole package, so it must not be used together with t.Parallel.
+func EnableDryRun(t *testing.T) {
+	t.Helper()
+	previous := Runner
+	Runner = DryRunRunner{T: t}
+	t.Cleanup(func() {
+		Runner = previous
+	})
+}
+
+// DryRunEnabled reports whether Runner is a DryRunRunner, i.e. whether EnableDryRun is in effect
+func DryRunEnabled() bool {
+	_, ok := Runner.(DryRunRunner)
+	return ok
+}
+
+// Run logs the command and returns canned success output
+func (r DryRunRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	r.T.Helper()
+	Logf(r.T, "[dry-run] %s %s", name, strings.Join(args, " "))
+	return dryRunOutput(args, nil), nil
+}
+
+// RunWithInput logs the command and its input and returns canned success output
+func (r DryRunRunner) RunWithInput(ctx context.Context, input []byte, name string, args ...string) ([]byte, error) {
+	r.T.Helper()
+	Logf(r.T, "[dry-run] %s %s <<EOF\n%s\nEOF", name, strings.Join(args, " "), strings.TrimSuffix(string(input), "\n"))
+	return dryRunOutput(args, input), nil
+}
+
+// dryRunOutput returns the output kubectl apply or create would print for the applied YAML, e.g. "taskrun.tekton.dev/name created".
+// The YAML is read from input for "-f -".
+func dryRunOutput(args []string, input []byte) []byte {
+	if len(args) == 0 || (args[0] != "apply" && args[0] != "create") {
+		return nil
+	}
+	data := input
+	for i := 1; i+1 < len(args); i++ {
+		if args[i] == "-f" && args[i+1] != "-" {
+			var err error
+			if data, err = os.ReadFile(args[i+1]); err != nil {
+				return nil
+			}
+		}
+	}
+
+	var output bytes.Buffer
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc struct {
+			APIVersion string `yaml:"apiVersion"`
+			Kind       string `yaml:"kind"`
+			Metadata   struct {
+				Name         string `yaml:"name"`
+				GenerateName string `yaml:"generateName"`
+			} `yaml:"metadata"`
+		}
+		if err := decoder.Decode(&doc); err != nil {
+			break
+		}
+		if doc.Kind == "" {
+			continue
+		}
+		resource := strings.ToLower(doc.Kind)
+		if group, _, ok := strings.Cut(doc.APIVersion, "/"); ok {
+			resource += "." + group
+		}
+		name := doc.Metadata.Name
+		if name == "" {
+			name = doc.Metadata.GenerateName + "dryrun"
+		}
+		fmt.Fprintf(&output, "%s/%s created\n", resource, name)
+	}
+	return output.Bytes()
+}
+
+// CheckDependencies verifies that the command line tools this package shells out to are installed,
+// returning a single error listing every missing tool
+func CheckDependencies() error {
+	var missing []string
+	for _, tool := range requiredTools {
+		if _, err := exec.LookPath(binaryPath(tool)); err != nil {
+			missing = append(missing, binaryPath(tool))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("required tools not found in PATH: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// binaryPath returns the path of the command line tool set in its CATALOG_<TOOL>_BIN environment variable,
+// e.g. CATALOG_KUBECTL_BIN=/opt/bin/kubectl.1.29, or the bare tool name to look up in PATH if it is not set
+func binaryPath(tool string) string {
+	if path := os.Getenv("CATALOG_" + strings.ToUpper(tool) + "_BIN"); path != "" {
+		return path
+	}
+	return tool
+}
@@ -17,36 +17,105 @@ package resourcemanager
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os/exec"
+	"math"
+	"net/http"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
 	"knative.dev/pkg/apis"
 )
 
 const (
-	tektonRunPattern = `(?m)^(taskrun|pipelinerun)\.tekton\.dev/(\S+)\s+created$`
+	tektonRunPattern      = `(?m)^(taskrun|pipelinerun|customrun|run)\.tekton\.dev/(\S+)\s+(?:created|configured|unchanged|serverside-applied)$`
+	namespacePollInterval = 2 * time.Second
+	podPollInterval       = 2 * time.Second
+
+	// rewatchBackoff is the first wait before re-watching a run whose watch was closed without any update,
+	// doubling up to maxRewatchBackoff while the watches keep closing
+	rewatchBackoff    = 200 * time.Millisecond
+	maxRewatchBackoff = 5 * time.Second
+
+	// DefaultWatchTimeout is the timeout used when waiting for a run with a zero timeout
+	DefaultWatchTimeout = 10 * time.Minute
+
+	// fieldManager is the field manager owning the fields set by server-side applies
+	fieldManager = "catalog-infra"
 )
 
-// TektonRun represents a Tekton TaskRun or PipelineRun
+// TektonRun represents a Tekton TaskRun, PipelineRun, CustomRun or Run
 type TektonRun struct {
 	Name string
 	Kind string
 }
 
+// WaitError is returned when a Tekton TaskRun or PipelineRun does not complete with the expected condition
+type WaitError struct {
+	TektonRun TektonRun
+	// Condition is the last observed Succeeded condition of the run, or nil if none was observed
+	Condition *apis.Condition
+	Reason    string
+	// Err is the sentinel error classifying the failure, ErrRunFailed or ErrRunTimedOut, the context error if the wait was
+	// cancelled, or nil for watch errors
+	Err error
+}
+
+// Error implements error
+func (e *WaitError) Error() string {
+	return fmt.Sprintf("%s (last condition: %s)", e.Reason, formatCondition(e.Condition))
+}
+
+// Unwrap returns the sentinel error classifying the failure, or the context error if the wait was cancelled
+func (e *WaitError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyOptions configures optional behavior of the kubectl applies of the *Context apply functions
+type ApplyOptions struct {
+	// ServerSideApply makes the apply a server-side apply. Client-side apply stores the whole object in the
+	// last-applied-configuration annotation, which fails for large Tekton objects once it exceeds the annotation size limit.
+	ServerSideApply bool
+}
+
+// WaitOptions configures optional behavior of WaitForTektonRunCompletionWithOptions
+type WaitOptions struct {
+	// KubeClient is the kubernetes client used to inspect the pods of the run and, on failure, the events of the namespace
+	KubeClient kubernetes.Interface
+	// StreamLogs tails the logs of the run's pods to t.Log as they arrive. Requires KubeClient.
+	StreamLogs bool
+	// ImagePullGracePeriod is how long a container of the run may fail to pull its image before the wait fails,
+	// instead of waiting out the whole timeout. Only checked with a KubeClient. Zero means DefaultImagePullGracePeriod.
+	ImagePullGracePeriod time.Duration
+}
+
 // ApplyStepActionYAML applies the Tekton StepAction YAML file to the kubernetes cluster
 func ApplyStepActionYAML(stepActionFilePath, namespace string) error {
-	cmd := exec.Command("kubectl", "apply", "-f", stepActionFilePath, "-n", namespace)
-	output, err := cmd.CombinedOutput()
+	return ApplyStepActionYAMLContext(context.TODO(), stepActionFilePath, namespace, ApplyOptions{})
+}
+
+// ApplyStepActionYAMLContext applies the Tekton StepAction YAML file to the kubernetes cluster with the options, stopping kubectl when ctx is done
+func ApplyStepActionYAMLContext(ctx context.Context, stepActionFilePath, namespace string, opts ApplyOptions) error {
+	_, err := runWithRetry(ctx, nil, "kubectl", applyArgs(stepActionFilePath, namespace, opts)...)
 	if err != nil {
-		return fmt.Errorf("failed to apply Tekton YAML file: %v\n%s", err, output)
+		return classify(ErrApplyFailed, fmt.Errorf("failed to apply Tekton YAML file: %v", err))
 	}
 	return nil
 }
@@ -54,113 +123,701 @@ func ApplyStepActionYAML(stepActionFilePath, namespace string) error {
 // ApplyTestYAML applies the Test YAML file to the kubernetes cluster and returns the Tekton TaskRun or PipelineRun
 func ApplyTestYAML(t *testing.T, testFilePath, namespace string) TektonRun {
 	t.Helper()
-	cmd := exec.Command("kubectl", "apply", "-f", testFilePath, "-n", namespace)
-	output, err := cmd.CombinedOutput()
+	tektonRun, err := ApplyTestYAMLE(testFilePath, namespace)
+	if err != nil {
+		Fatalf(t, "%v", err)
+	}
+	return tektonRun
+}
+
+// ApplyTestYAMLE applies the Test YAML file to the kubernetes cluster and returns the Tekton TaskRun or PipelineRun, or an error if the apply fails
+func ApplyTestYAMLE(testFilePath, namespace string) (TektonRun, error) {
+	return ApplyTestYAMLContext(context.TODO(), testFilePath, namespace, ApplyOptions{})
+}
+
+// ApplyTestYAMLContext applies the Test YAML file to the kubernetes cluster with the options and returns the Tekton TaskRun or PipelineRun,
+// or an error if the apply fails. kubectl is stopped when ctx is done.
+func ApplyTestYAMLContext(ctx context.Context, testFilePath, namespace string, opts ApplyOptions) (TektonRun, error) {
+	tektonRuns, err := ApplyTestYAMLRunsContext(ctx, testFilePath, namespace, opts)
+	if err != nil {
+		return TektonRun{}, err
+	}
+	return tektonRuns[0], nil
+}
+
+// ApplyTestYAMLRuns applies the Test YAML file to the kubernetes cluster and returns every Tekton TaskRun and PipelineRun it created
+func ApplyTestYAMLRuns(t *testing.T, testFilePath, namespace string) []TektonRun {
+	t.Helper()
+	tektonRuns, err := ApplyTestYAMLRunsE(testFilePath, namespace)
+	if err != nil {
+		Fatalf(t, "%v", err)
+	}
+	return tektonRuns
+}
+
+// ApplyTestYAMLRunsE applies the Test YAML file to the kubernetes cluster and returns every Tekton TaskRun and PipelineRun it created, or an error if the apply fails
+func ApplyTestYAMLRunsE(testFilePath, namespace string) ([]TektonRun, error) {
+	return ApplyTestYAMLRunsContext(context.TODO(), testFilePath, namespace, ApplyOptions{})
+}
+
+// ApplyTestYAMLRunsContext applies the Test YAML file to the kubernetes cluster with the options and returns every Tekton TaskRun and
+// PipelineRun it created, or an error if the apply fails. kubectl is stopped when ctx is done.
+func ApplyTestYAMLRunsContext(ctx context.Context, testFilePath, namespace string, opts ApplyOptions) ([]TektonRun, error) {
+	if err := ValidateTektonYAML(testFilePath); err != nil {
+		return nil, err
+	}
+	output, err := runWithRetry(ctx, nil, "kubectl", applyArgs(testFilePath, namespace, opts)...)
+	if err != nil {
+		return nil, classify(ErrApplyFailed, fmt.Errorf("failed to apply Test YAML file: %v", err))
+	}
+	tektonRuns, err := GetTektonRuns(string(output))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Tekton Run: %v", err)
+	}
+	return tektonRuns, nil
+}
+
+// ApplyDefinition applies a Tekton Task or Pipeline definition to the kubernetes cluster with the options without expecting a run to be created
+func ApplyDefinition(ctx context.Context, definitionFilePath, namespace string, opts ApplyOptions) error {
+	_, err := runWithRetry(ctx, nil, "kubectl", applyArgs(definitionFilePath, namespace, opts)...)
+	if err != nil {
+		return classify(ErrApplyFailed, fmt.Errorf("failed to apply definition YAML file: %v", err))
+	}
+	return nil
+}
+
+// ApplyTektonManifest applies the in-memory YAML or JSON manifest to the kubernetes cluster with the options by piping it to kubectl apply,
+// so generated resources do not need a fixture file. It returns the kubectl output, from which GetTektonRuns extracts created runs.
+func ApplyTektonManifest(ctx context.Context, manifest, namespace string, opts ApplyOptions) (string, error) {
+	output, err := runWithRetry(ctx, []byte(manifest), "kubectl", applyArgs("-", namespace, opts)...)
+	if err != nil {
+		return "", classify(ErrApplyFailed, fmt.Errorf("failed to apply manifest: %v", err))
+	}
+	return string(output), nil
+}
+
+// applyArgs returns the kubectl arguments applying the file, or stdin for "-", to the namespace with the options
+func applyArgs(filePath, namespace string, opts ApplyOptions) []string {
+	args := []string{"apply", "-f", filePath, "-n", namespace}
+	if opts.ServerSideApply {
+		args = append(args, "--server-side", "--field-manager="+fieldManager)
+	}
+	return args
+}
+
+// CreateRunForRef creates a TaskRun or PipelineRun referencing the Task or Pipeline named refName, already applied with ApplyDefinition.
+// The kind is the kind of the referenced definition ("Task" or "Pipeline") and the run name is generated from refName.
+func CreateRunForRef(ctx context.Context, refName, kind string, params map[string]string, namespace string) (TektonRun, error) {
+	var runParams v1.Params
+	for name, value := range params {
+		runParams = append(runParams, v1.Param{Name: name, Value: *v1.NewStructuredValues(value)})
+	}
+	sort.Slice(runParams, func(i, j int) bool { return runParams[i].Name < runParams[j].Name })
+
+	objectMeta := metav1.ObjectMeta{GenerateName: refName + "-"}
+	var run interface{}
+	switch strings.ToLower(kind) {
+	case "task":
+		run = &v1.TaskRun{
+			TypeMeta:   metav1.TypeMeta{APIVersion: v1.SchemeGroupVersion.String(), Kind: "TaskRun"},
+			ObjectMeta: objectMeta,
+			Spec:       v1.TaskRunSpec{TaskRef: &v1.TaskRef{Name: refName}, Params: runParams},
+		}
+	case "pipeline":
+		run = &v1.PipelineRun{
+			TypeMeta:   metav1.TypeMeta{APIVersion: v1.SchemeGroupVersion.String(), Kind: "PipelineRun"},
+			ObjectMeta: objectMeta,
+			Spec:       v1.PipelineRunSpec{PipelineRef: &v1.PipelineRef{Name: refName}, Params: runParams},
+		}
+	default:
+		return TektonRun{}, fmt.Errorf("unsupported Tekton ref kind: %s", kind)
+	}
+	manifest, err := json.Marshal(run)
 	if err != nil {
-		t.Fatalf("failed to apply Test YAML file: %v\n%s", err, output)
+		return TektonRun{}, fmt.Errorf("failed to marshal run for %s '%s': %v", kind, refName, err)
+	}
+
+	// kubectl apply does not support generateName, so create the run instead
+	output, err := Runner.RunWithInput(ctx, manifest, "kubectl", "create", "-f", "-", "-n", namespace)
+	if err != nil {
+		return TektonRun{}, classify(ErrApplyFailed, fmt.Errorf("failed to create run for %s '%s': %v\n%s", kind, refName, err, output))
 	}
 	tektonRun, err := getTektonRun(string(output))
 	if err != nil {
-		t.Fatalf("failed to get Tekton Run: %v", err)
+		return TektonRun{}, fmt.Errorf("failed to get Tekton Run: %v", err)
 	}
-	return tektonRun
+	return tektonRun, nil
 }
 
 // WaitForTektonRunCompletion waits for the Tekton TaskRun or PipelineRun to complete with the expected condition within the timeout
-func WaitForTektonRunCompletion(t *testing.T, tektonClient *versioned.Clientset, tektonRun TektonRun, watchTimeout time.Duration, expectedCondition, namespace string) {
+func WaitForTektonRunCompletion(t *testing.T, tektonClient versioned.Interface, tektonRun TektonRun, watchTimeout time.Duration, expectedCondition, namespace string) {
 	t.Helper()
-	var watcher watch.Interface
-	var err error
+	WaitForTektonRunCompletionWithOptions(t, tektonClient, tektonRun, watchTimeout, expectedCondition, namespace, WaitOptions{})
+}
 
-	// Calculate timeout in seconds
-	timeoutSeconds := int64(watchTimeout.Seconds())
+// WaitForTektonRunCompletionWithOptions waits for the Tekton TaskRun or PipelineRun to complete with the expected condition within the timeout,
+// with the optional behavior configured in opts
+func WaitForTektonRunCompletionWithOptions(t *testing.T, tektonClient versioned.Interface, tektonRun TektonRun, watchTimeout time.Duration, expectedCondition, namespace string, opts WaitOptions) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
 
-	switch strings.ToLower(tektonRun.Kind) {
-	case "taskrun":
-		watcher, err = tektonClient.TektonV1().TaskRuns(namespace).Watch(context.TODO(), metav1.ListOptions{
-			FieldSelector:  fmt.Sprintf("metadata.name=%s", tektonRun.Name),
-			TimeoutSeconds: &timeoutSeconds,
-		})
+	var wg sync.WaitGroup
+	if opts.StreamLogs {
+		if opts.KubeClient == nil {
+			Fatalf(t, "streaming logs requires a KubeClient in the WaitOptions")
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			streamTektonRunLogs(ctx, t, opts.KubeClient, tektonRun, namespace)
+		}()
+	}
+
+	var imagePullErr error
+	if opts.KubeClient != nil {
+		gracePeriod := opts.ImagePullGracePeriod
+		if gracePeriod == 0 {
+			gracePeriod = DefaultImagePullGracePeriod
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := detectImagePullFailure(ctx, opts.KubeClient, tektonRun, namespace, gracePeriod); err != nil {
+				imagePullErr = err
+				// Stop the wait, the run cannot make progress
+				cancel()
+			}
+		}()
+	}
+
+	err := WaitForTektonRunCompletionE(ctx, tektonClient, tektonRun, watchTimeout, expectedCondition, namespace)
+	// Stop streaming before failing, t.Log must not be called after the test completes
+	cancel()
+	wg.Wait()
+	if err != nil && imagePullErr != nil {
+		err = imagePullErr
+	}
+	if err != nil {
+		if opts.KubeClient != nil {
+			if events, eventsErr := GetNamespaceEvents(context.TODO(), opts.KubeClient, namespace); eventsErr != nil {
+				Logf(t, "%v", eventsErr)
+			} else {
+				Logf(t, "events in namespace %s:\n%s", namespace, events)
+			}
+			if containers, containersErr := ListTektonRunContainers(context.TODO(), opts.KubeClient, tektonRun, namespace); containersErr == nil && len(containers) > 0 {
+				Logf(t, "containers of %s %s, see GetStepLogs and GetSidecarLogs:\n  %s", tektonRun.Kind, tektonRun.Name, strings.Join(containers, "\n  "))
+			}
+		}
+		DumpTektonRunArtifactsWithClient(t, opts.KubeClient, tektonClient, tektonRun, namespace)
+		Fatalf(t, "%v", err)
+	}
+}
+
+// WaitForTektonRunsCompletion waits for all the Tekton TaskRuns and PipelineRuns to complete with the expected condition within the timeout.
+// The runs are watched concurrently, so the timeout applies to all runs together, and the first run that fails stops the other watches.
+func WaitForTektonRunsCompletion(t *testing.T, tektonClient versioned.Interface, tektonRuns []TektonRun, watchTimeout time.Duration, expectedCondition, namespace string) {
+	t.Helper()
+	if err := WaitForTektonRunsCompletionE(context.TODO(), tektonClient, tektonRuns, watchTimeout, expectedCondition, namespace); err != nil {
+		for _, tektonRun := range tektonRuns {
+			DumpTektonRunArtifactsWithClient(t, nil, tektonClient, tektonRun, namespace)
+		}
+		Fatalf(t, "%v", err)
+	}
+}
+
+// WaitForTektonRunsCompletionE waits for all the Tekton runs to complete with the expected condition within the timeout, e.g. the runs
+// returned by ApplyTestYAMLRunsE. Every run is watched in its own goroutine and the function returns once all watches have stopped.
+// The first failure cancels the remaining watches, whose resulting errors are left out of the returned error.
+// A zero timeout means DefaultWatchTimeout.
+func WaitForTektonRunsCompletionE(ctx context.Context, tektonClient versioned.Interface, tektonRuns []TektonRun, watchTimeout time.Duration, expectedCondition, namespace string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, tektonRun := range tektonRuns {
+		wg.Add(1)
+		go func(tektonRun TektonRun) {
+			defer wg.Done()
+			err := WaitForTektonRunCompletionE(ctx, tektonClient, tektonRun, watchTimeout, expectedCondition, namespace)
+			if err == nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if ctx.Err() != nil && len(errs) > 0 {
+				// The watch was cancelled because another run failed first
+				return
+			}
+			errs = append(errs, fmt.Errorf("%s %s: %w", tektonRun.Kind, tektonRun.Name, err))
+			cancel()
+		}(tektonRun)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// WaitForTektonRunCompletionE waits for the Tekton TaskRun or PipelineRun to complete with the expected condition within the timeout.
+// If the run does not complete with the expected condition, the returned *WaitError holds the last observed Succeeded condition.
+// Watches closed by the API server before the timeout are re-established from the last observed resourceVersion.
+// A zero timeout means DefaultWatchTimeout.
+func WaitForTektonRunCompletionE(ctx context.Context, tektonClient versioned.Interface, tektonRun TektonRun, watchTimeout time.Duration, expectedCondition, namespace string) error {
+	return WaitForTektonRunCondition(ctx, tektonClient, tektonRun, watchTimeout, expectedCondition, corev1.ConditionTrue, namespace)
+}
+
+// WaitForTektonRunCondition waits for the Tekton run to complete with the condition of the given type in the given status,
+// e.g. Succeeded/False for a negative test, within the timeout. It fails as soon as the run completes otherwise, rather than
+// waiting out the timeout. A zero timeout means DefaultWatchTimeout.
+func WaitForTektonRunCondition(ctx context.Context, tektonClient versioned.Interface, tektonRun TektonRun, watchTimeout time.Duration, conditionType string, conditionStatus corev1.ConditionStatus, namespace string) error {
+	if watchTimeout == 0 {
+		watchTimeout = DefaultWatchTimeout
+	}
+	var lastCondition *apis.Condition
+
+	done, err := watchTektonRunUntil(ctx, tektonClient, tektonRun, watchTimeout, namespace, func(obj runtime.Object) (bool, error) {
+		_, done, conditions := tektonRunState(obj)
+		lastCondition = succeededCondition(conditions)
+		if !done {
+			return false, nil
+		}
+		if meetExpectedCondition(conditions, conditionType, conditionStatus) {
+			return true, nil
+		}
+		// The run is finished, so it will never meet the expected condition
+		return true, &WaitError{TektonRun: tektonRun, Condition: lastCondition, Reason: fmt.Sprintf("%s %s completed without meeting expected condition %s=%s", tektonRun.Kind, tektonRun.Name, conditionType, conditionStatus), Err: ErrRunFailed}
+	})
+	var waitErr *WaitError
+	switch {
+	case errors.As(err, &waitErr):
+		return err
+	case isContextError(err):
+		return &WaitError{TektonRun: tektonRun, Condition: lastCondition, Reason: fmt.Sprintf("stopped waiting for %s %s: %v", tektonRun.Kind, tektonRun.Name, err), Err: err}
+	case err != nil:
+		return &WaitError{TektonRun: tektonRun, Condition: lastCondition, Reason: err.Error()}
+	case !done:
+		return &WaitError{TektonRun: tektonRun, Condition: lastCondition, Reason: fmt.Sprintf("watch timed out after %v", watchTimeout), Err: ErrRunTimedOut}
+	}
+	return nil
+}
+
+// WaitForTektonRunStarted waits until a pod of the Tekton TaskRun or PipelineRun is running, without waiting for the run to complete,
+// e.g. to then exec into a step or check a sidecar. The pods are polled with the kubernetes client, because a PipelineRun reports
+// the Running reason as soon as it is reconciled, before any of its pods exist. CustomRuns and Runs have no pods and are started
+// once their controller picks them up. A run that has already completed counts as started. A zero timeout means DefaultWatchTimeout.
+func WaitForTektonRunStarted(ctx context.Context, tektonClient versioned.Interface, kubeClient kubernetes.Interface, tektonRun TektonRun, watchTimeout time.Duration, namespace string) error {
+	if watchTimeout == 0 {
+		watchTimeout = DefaultWatchTimeout
+	}
+	if _, err := tektonRunPodLabel(tektonRun); err == nil {
+		return waitForTektonRunPodRunning(ctx, tektonClient, kubeClient, tektonRun, watchTimeout, namespace)
+	}
+	var lastCondition *apis.Condition
+
+	done, err := watchTektonRunUntil(ctx, tektonClient, tektonRun, watchTimeout, namespace, func(obj runtime.Object) (bool, error) {
+		started, done, conditions := tektonRunState(obj)
+		lastCondition = succeededCondition(conditions)
+		return started || done, nil
+	})
+	switch {
+	case isContextError(err):
+		return &WaitError{TektonRun: tektonRun, Condition: lastCondition, Reason: fmt.Sprintf("stopped waiting for %s %s to start: %v", tektonRun.Kind, tektonRun.Name, err), Err: err}
+	case err != nil:
+		return &WaitError{TektonRun: tektonRun, Condition: lastCondition, Reason: err.Error()}
+	}
+	if !done {
+		return &WaitError{TektonRun: tektonRun, Condition: lastCondition, Reason: fmt.Sprintf("%s %s did not start within %v", tektonRun.Kind, tektonRun.Name, watchTimeout), Err: ErrRunTimedOut}
+	}
+	return nil
+}
+
+// waitForTektonRunPodRunning polls the pods of the Tekton TaskRun or PipelineRun until one of them is running or the run has completed.
+// Errors getting the run or its pods are retried, and the last one is reported if the run does not start in time.
+func waitForTektonRunPodRunning(ctx context.Context, tektonClient versioned.Interface, kubeClient kubernetes.Interface, tektonRun TektonRun, watchTimeout time.Duration, namespace string) error {
+	if kubeClient == nil {
+		return fmt.Errorf("waiting for %s %s to start requires a kubernetes client", tektonRun.Kind, tektonRun.Name)
+	}
+	var lastCondition *apis.Condition
+	var lastErr error
+	err := wait.PollUntilContextTimeout(ctx, podPollInterval, watchTimeout, true, func(ctx context.Context) (bool, error) {
+		cond, err := GetTektonRunCondition(ctx, tektonClient, tektonRun, namespace)
 		if err != nil {
-			t.Fatalf("failed to start watch for TaskRun: %v", err)
+			lastErr = err
+			return false, nil
 		}
-	case "pipelinerun":
-		watcher, err = tektonClient.TektonV1().PipelineRuns(namespace).Watch(context.TODO(), metav1.ListOptions{
-			FieldSelector:  fmt.Sprintf("metadata.name=%s", tektonRun.Name),
-			TimeoutSeconds: &timeoutSeconds,
-		})
+		lastCondition = &cond
+		if cond.Status != corev1.ConditionUnknown {
+			return true, nil
+		}
+		pods, err := listTektonRunPods(ctx, kubeClient, tektonRun, namespace)
 		if err != nil {
-			t.Fatalf("failed to start watch for PipelineRun: %v", err)
+			lastErr = err
+			return false, nil
 		}
-	default:
-		t.Fatalf("unsupported Tekton Run kind: %s", tektonRun.Kind)
-	}
-	defer watcher.Stop()
-
-	for event := range watcher.ResultChan() {
-		switch event.Type {
-		case watch.Error:
-			t.Fatalf("watch error: %v", event.Object)
-		case watch.Modified, watch.Added:
-			switch run := event.Object.(type) {
-			case *v1.TaskRun:
-				if run.IsDone() && meetExpectedCondition(run.Status.Conditions, expectedCondition) {
-					return
-				}
-			case *v1.PipelineRun:
-				if run.IsDone() && meetExpectedCondition(run.Status.Conditions, expectedCondition) {
-					return
+		for _, pod := range pods {
+			if pod.Status.Phase == corev1.PodRunning {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() != nil:
+		return &WaitError{TektonRun: tektonRun, Condition: lastCondition, Reason: fmt.Sprintf("stopped waiting for %s %s to start: %v", tektonRun.Kind, tektonRun.Name, ctx.Err()), Err: ctx.Err()}
+	case lastErr != nil:
+		return &WaitError{TektonRun: tektonRun, Condition: lastCondition, Reason: fmt.Sprintf("%s %s did not start within %v, last error: %v", tektonRun.Kind, tektonRun.Name, watchTimeout, lastErr), Err: ErrRunTimedOut}
+	}
+	return &WaitError{TektonRun: tektonRun, Condition: lastCondition, Reason: fmt.Sprintf("%s %s did not start within %v", tektonRun.Kind, tektonRun.Name, watchTimeout), Err: ErrRunTimedOut}
+}
+
+// watchTektonRunUntil watches the Tekton run until check reports it done for an observed state of the run, or returns an error.
+// Watches closed by the API server before the timeout are re-established from the last observed resourceVersion.
+// It returns false if the timeout elapses first, and ctx.Err() if ctx is done first.
+func watchTektonRunUntil(ctx context.Context, tektonClient versioned.Interface, tektonRun TektonRun, watchTimeout time.Duration, namespace string, check func(obj runtime.Object) (bool, error)) (bool, error) {
+	deadline := time.Now().Add(watchTimeout)
+	var resourceVersion string
+	backoff := rewatchBackoff
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false, nil
+		}
+		watcher, err := watchTektonRun(ctx, tektonClient, tektonRun, namespace, resourceVersion, remaining)
+		if err != nil {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			return false, err
+		}
+
+		received := false
+		done, err := func() (bool, error) {
+			defer watcher.Stop()
+			for event := range watcher.ResultChan() {
+				switch event.Type {
+				case watch.Error:
+					if status, ok := event.Object.(*metav1.Status); ok && status.Code == http.StatusGone {
+						// The resourceVersion is too old, so re-watch from the current state
+						resourceVersion = ""
+						return false, nil
+					}
+					return true, fmt.Errorf("watch error: %v", event.Object)
+				case watch.Modified, watch.Added:
+					received = true
+					if accessor, err := meta.Accessor(event.Object); err == nil {
+						resourceVersion = accessor.GetResourceVersion()
+					}
+					if done, err := check(event.Object); done || err != nil {
+						return true, err
+					}
 				}
 			}
+			// The API server closed the watch, re-establish it if there is time left
+			return false, nil
+		}()
+		if done || err != nil {
+			return done && err == nil, err
+		}
+
+		// Back off before re-watching a watch that was closed without any update, so an API server or proxy that keeps
+		// closing watches is not hit in a tight loop
+		if received {
+			backoff = rewatchBackoff
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(min(backoff, time.Until(deadline))):
 		}
+		backoff = min(2*backoff, maxRewatchBackoff)
 	}
+}
+
+// isContextError reports whether the error is a cancelled or expired context rather than a failure of the run or the watch
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
 
-	t.Fatalf("watch timed out after %v", watchTimeout)
+// tektonRunState returns whether the Tekton TaskRun, PipelineRun, CustomRun or Run has started and completed, and its conditions.
+// Whether a TaskRun or PipelineRun has started depends on its pods, which the run does not show, so it is never reported as started.
+func tektonRunState(obj runtime.Object) (started, done bool, conditions []apis.Condition) {
+	switch run := obj.(type) {
+	case *v1.TaskRun:
+		return false, run.IsDone(), run.Status.Conditions
+	case *v1.PipelineRun:
+		return false, run.IsDone(), run.Status.Conditions
+	case *v1beta1.CustomRun:
+		return run.HasStarted(), run.IsDone(), run.Status.Conditions
+	case *v1alpha1.Run:
+		return run.HasStarted(), run.IsDone(), run.Status.Conditions
+	}
+	return false, false, nil
 }
 
-// meetExpectedCondition checks if the Tekton TaskRun or PipelineRun meets the expected condition
-func meetExpectedCondition(conditions []apis.Condition, expectedCondition string) bool {
+// succeededCondition returns the Succeeded condition from the conditions, or nil if there is none
+func succeededCondition(conditions []apis.Condition) *apis.Condition {
+	for i := range conditions {
+		if conditions[i].Type == apis.ConditionSucceeded {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// watchTektonRun starts a watch on the Tekton TaskRun or PipelineRun from the resourceVersion, or from the current state if it is empty
+func watchTektonRun(ctx context.Context, tektonClient versioned.Interface, tektonRun TektonRun, namespace, resourceVersion string, watchTimeout time.Duration) (watch.Interface, error) {
+	// Calculate timeout in seconds, rounding up so a sub-second remainder does not disable the server-side timeout
+	timeoutSeconds := int64(math.Ceil(watchTimeout.Seconds()))
+	listOptions := metav1.ListOptions{
+		FieldSelector:   fmt.Sprintf("metadata.name=%s", tektonRun.Name),
+		ResourceVersion: resourceVersion,
+		TimeoutSeconds:  &timeoutSeconds,
+	}
+
+	switch strings.ToLower(tektonRun.Kind) {
+	case "taskrun":
+		watcher, err := tektonClient.TektonV1().TaskRuns(namespace).Watch(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start watch for TaskRun: %v", err)
+		}
+		return watcher, nil
+	case "pipelinerun":
+		watcher, err := tektonClient.TektonV1().PipelineRuns(namespace).Watch(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start watch for PipelineRun: %v", err)
+		}
+		return watcher, nil
+	case "customrun":
+		watcher, err := tektonClient.TektonV1beta1().CustomRuns(namespace).Watch(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start watch for CustomRun: %v", err)
+		}
+		return watcher, nil
+	case "run":
+		watcher, err := tektonClient.TektonV1alpha1().Runs(namespace).Watch(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start watch for Run: %v", err)
+		}
+		return watcher, nil
+	default:
+		return nil, fmt.Errorf("unsupported Tekton Run kind: %s", tektonRun.Kind)
+	}
+}
+
+// meetExpectedCondition checks if the Tekton TaskRun or PipelineRun has the expected condition in the expected status
+func meetExpectedCondition(conditions []apis.Condition, expectedCondition string, expectedStatus corev1.ConditionStatus) bool {
 	for _, cond := range conditions {
-		if string(cond.Type) == expectedCondition && cond.Status == "True" {
+		if string(cond.Type) == expectedCondition && cond.Status == expectedStatus {
 			return true
 		}
 	}
 	return false
 }
 
+// formatCondition formats the condition for failure messages
+func formatCondition(cond *apis.Condition) string {
+	if cond == nil {
+		return "no Succeeded condition"
+	}
+	return fmt.Sprintf("%s=%s, reason: %s, message: %s", cond.Type, cond.Status, cond.Reason, cond.Message)
+}
+
+// GetTektonRunCondition gets the current Succeeded condition of the Tekton TaskRun, PipelineRun, CustomRun or Run with a single Get.
+// A run that has not reported the condition yet returns it with status Unknown.
+func GetTektonRunCondition(ctx context.Context, tektonClient versioned.Interface, tektonRun TektonRun, namespace string) (apis.Condition, error) {
+	var cond *apis.Condition
+	switch strings.ToLower(tektonRun.Kind) {
+	case "taskrun":
+		taskRun, err := tektonClient.TektonV1().TaskRuns(namespace).Get(ctx, tektonRun.Name, metav1.GetOptions{})
+		if err != nil {
+			return apis.Condition{}, fmt.Errorf("failed to get TaskRun: %v", err)
+		}
+		cond = taskRun.Status.GetCondition(apis.ConditionSucceeded)
+	case "pipelinerun":
+		pipelineRun, err := tektonClient.TektonV1().PipelineRuns(namespace).Get(ctx, tektonRun.Name, metav1.GetOptions{})
+		if err != nil {
+			return apis.Condition{}, fmt.Errorf("failed to get PipelineRun: %v", err)
+		}
+		cond = pipelineRun.Status.GetCondition(apis.ConditionSucceeded)
+	case "customrun":
+		customRun, err := tektonClient.TektonV1beta1().CustomRuns(namespace).Get(ctx, tektonRun.Name, metav1.GetOptions{})
+		if err != nil {
+			return apis.Condition{}, fmt.Errorf("failed to get CustomRun: %v", err)
+		}
+		cond = customRun.Status.GetCondition(apis.ConditionSucceeded)
+	case "run":
+		run, err := tektonClient.TektonV1alpha1().Runs(namespace).Get(ctx, tektonRun.Name, metav1.GetOptions{})
+		if err != nil {
+			return apis.Condition{}, fmt.Errorf("failed to get Run: %v", err)
+		}
+		cond = run.Status.GetCondition(apis.ConditionSucceeded)
+	default:
+		return apis.Condition{}, fmt.Errorf("unsupported Tekton Run kind: %s", tektonRun.Kind)
+	}
+	if cond == nil {
+		return apis.Condition{Type: apis.ConditionSucceeded, Status: corev1.ConditionUnknown}, nil
+	}
+	return *cond, nil
+}
+
 // getTektonRun extracts a single Tekton TaskRun or PipelineRun from the output
 func getTektonRun(output string) (TektonRun, error) {
+	tektonRuns, err := GetTektonRuns(output)
+	if err != nil {
+		return TektonRun{}, err
+	}
+	return tektonRuns[0], nil
+}
+
+// GetTektonRuns extracts all distinct Tekton TaskRuns and PipelineRuns from the output, in the order they were created
+func GetTektonRuns(output string) ([]TektonRun, error) {
 	re := regexp.MustCompile(tektonRunPattern)
-	matches := re.FindAllStringSubmatch(output, -1)
-	if len(matches) == 0 {
-		return TektonRun{}, fmt.Errorf("no TaskRun or PipelineRun found in the output")
+	var tektonRuns []TektonRun
+	seen := map[TektonRun]bool{}
+	for _, match := range re.FindAllStringSubmatch(output, -1) {
+		if len(match) <= 2 {
+			continue
+		}
+		tektonRun := TektonRun{
+			Name: match[2],
+			Kind: match[1],
+		}
+		// kubectl may report the same run more than once, e.g. on server-side apply retries
+		if seen[tektonRun] {
+			continue
+		}
+		seen[tektonRun] = true
+		tektonRuns = append(tektonRuns, tektonRun)
 	}
-	if len(matches[0]) > 2 {
-		return TektonRun{
-			Name: matches[0][2],
-			Kind: matches[0][1],
-		}, nil
+	if len(tektonRuns) == 0 {
+		return nil, fmt.Errorf("no TaskRun or PipelineRun found in the output")
+	}
+	return tektonRuns, nil
+}
+
+// CreateNamespace creates a namespace for testing in the kubernetes cluster. An already existing namespace is not an error.
+func CreateNamespace(client kubernetes.Interface, namespace string) error {
+	return CreateNamespaceContext(context.TODO(), client, namespace)
+}
+
+// CreateNamespaceContext creates a namespace for testing in the kubernetes cluster, giving up when ctx is done. An already existing namespace is not an error.
+func CreateNamespaceContext(ctx context.Context, client kubernetes.Interface, namespace string) error {
+	return CreateNamespaceWithMetaContext(ctx, client, namespace, nil, nil)
+}
 
+// CreateNamespaceWithMeta creates a namespace for testing with the given labels and annotations, e.g. to tag it for automated cleanup.
+// A label with an empty value is set as a key-only label, which still matches an existence selector like "catalog-infra/ttl".
+// An already existing namespace is not an error and its labels and annotations are left unchanged.
+func CreateNamespaceWithMeta(client kubernetes.Interface, namespace string, labels, annotations map[string]string) error {
+	return CreateNamespaceWithMetaContext(context.TODO(), client, namespace, labels, annotations)
+}
+
+// CreateNamespaceWithMetaContext creates a namespace for testing with the given labels and annotations like CreateNamespaceWithMeta,
+// giving up when ctx is done
+func CreateNamespaceWithMetaContext(ctx context.Context, client kubernetes.Interface, namespace string, labels, annotations map[string]string) error {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+	}
+	if _, err := client.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return classify(ErrNamespaceCreateFailed, fmt.Errorf("failed to create namespace: %w", err))
 	}
-	return TektonRun{}, fmt.Errorf("no TaskRun or PipelineRun found in the output")
+	return nil
 }
 
-// CreateNamespace creates a namespace for testing in the kubernetes cluster
-func CreateNamespace(namespace string) error {
-	cmd := exec.Command("kubectl", "create", "namespace", namespace)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to create namespace: %v\n%s", err, output)
+// DeleteNamespace deletes the namespace and all resources in it. A namespace that does not exist is not an error.
+func DeleteNamespace(client kubernetes.Interface, namespace string) error {
+	return DeleteNamespaceContext(context.TODO(), client, namespace)
+}
+
+// DeleteNamespaceContext deletes the namespace and all resources in it, giving up when ctx is done. A namespace that does not exist is not an error.
+func DeleteNamespaceContext(ctx context.Context, client kubernetes.Interface, namespace string) error {
+	if err := client.CoreV1().Namespaces().Delete(ctx, namespace, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete namespace: %w", err)
 	}
 	return nil
 }
 
-// DeleteNamespace deletes the namespace and all resources in it
-func DeleteNamespace(namespace string) error {
-	cmd := exec.Command("kubectl", "delete", "namespace", namespace)
-	output, err := cmd.CombinedOutput()
+// DeleteNamespaceAndWait deletes the namespace and waits until it no longer exists or the timeout elapses,
+// so a following test can reuse the name without colliding with a Terminating namespace.
+// Errors getting the namespace while waiting are retried, and the last one is reported if the namespace is not deleted in time.
+func DeleteNamespaceAndWait(ctx context.Context, client kubernetes.Interface, namespace string, timeout time.Duration) error {
+	if err := DeleteNamespaceContext(ctx, client, namespace); err != nil {
+		return err
+	}
+	var lastErr error
+	err := wait.PollUntilContextTimeout(ctx, namespacePollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		_, err := client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			lastErr = err
+		}
+		return false, nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete namespace: %v\n%s", err, output)
+		if lastErr != nil {
+			return fmt.Errorf("namespace %s was not deleted within %v, last error getting it: %w", namespace, timeout, lastErr)
+		}
+		return fmt.Errorf("namespace %s was not deleted within %v: %w", namespace, timeout, err)
 	}
 	return nil
 }
+
+// DeleteTektonRun deletes a single Tekton TaskRun, PipelineRun, CustomRun or Run, leaving the rest of the namespace intact.
+// A run that does not exist is not an error.
+func DeleteTektonRun(ctx context.Context, tektonClient versioned.Interface, tektonRun TektonRun, namespace string) error {
+	var err error
+	switch strings.ToLower(tektonRun.Kind) {
+	case "taskrun":
+		err = tektonClient.TektonV1().TaskRuns(namespace).Delete(ctx, tektonRun.Name, metav1.DeleteOptions{})
+	case "pipelinerun":
+		err = tektonClient.TektonV1().PipelineRuns(namespace).Delete(ctx, tektonRun.Name, metav1.DeleteOptions{})
+	case "customrun":
+		err = tektonClient.TektonV1beta1().CustomRuns(namespace).Delete(ctx, tektonRun.Name, metav1.DeleteOptions{})
+	case "run":
+		err = tektonClient.TektonV1alpha1().Runs(namespace).Delete(ctx, tektonRun.Name, metav1.DeleteOptions{})
+	default:
+		return fmt.Errorf("unsupported Tekton Run kind: %s", tektonRun.Kind)
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete %s %s: %w", tektonRun.Kind, tektonRun.Name, err)
+	}
+	return nil
+}
+
+// ListTektonRuns lists all Tekton TaskRuns and PipelineRuns in the namespace, including runs created indirectly like the child
+// TaskRuns of a PipelineRun
+func ListTektonRuns(ctx context.Context, tektonClient versioned.Interface, namespace string) ([]TektonRun, error) {
+	taskRuns, err := tektonClient.TektonV1().TaskRuns(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list TaskRuns: %v", err)
+	}
+	pipelineRuns, err := tektonClient.TektonV1().PipelineRuns(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PipelineRuns: %v", err)
+	}
+
+	var tektonRuns []TektonRun
+	for _, taskRun := range taskRuns.Items {
+		tektonRuns = append(tektonRuns, TektonRun{Name: taskRun.Name, Kind: "taskrun"})
+	}
+	for _, pipelineRun := range pipelineRuns.Items {
+		tektonRuns = append(tektonRuns, TektonRun{Name: pipelineRun.Name, Kind: "pipelinerun"})
+	}
+	return tektonRuns, nil
+}
@@ -0,0 +1,282 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemanager
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"knative.dev/pkg/apis"
+)
+
+func TestGetTektonRuns(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    []TektonRun
+		wantErr bool
+	}{
+		{
+			name:   "created",
+			output: "taskrun.tekton.dev/test-run created\n",
+			want:   []TektonRun{{Name: "test-run", Kind: "taskrun"}},
+		},
+		{
+			name:   "configured",
+			output: "pipelinerun.tekton.dev/test-run configured\n",
+			want:   []TektonRun{{Name: "test-run", Kind: "pipelinerun"}},
+		},
+		{
+			name:   "unchanged",
+			output: "taskrun.tekton.dev/test-run unchanged\n",
+			want:   []TektonRun{{Name: "test-run", Kind: "taskrun"}},
+		},
+		{
+			name:   "server-side applied",
+			output: "taskrun.tekton.dev/test-run serverside-applied\n",
+			want:   []TektonRun{{Name: "test-run", Kind: "taskrun"}},
+		},
+		{
+			name:   "custom run",
+			output: "customrun.tekton.dev/test-run created\n",
+			want:   []TektonRun{{Name: "test-run", Kind: "customrun"}},
+		},
+		{
+			name:   "run",
+			output: "run.tekton.dev/test-run created\n",
+			want:   []TektonRun{{Name: "test-run", Kind: "run"}},
+		},
+		{
+			name: "multiple runs among other resources",
+			output: "stepaction.tekton.dev/test-step-action created\n" +
+				"task.tekton.dev/test-task unchanged\n" +
+				"taskrun.tekton.dev/first-run created\n" +
+				"pipelinerun.tekton.dev/second-run configured\n",
+			want: []TektonRun{{Name: "first-run", Kind: "taskrun"}, {Name: "second-run", Kind: "pipelinerun"}},
+		},
+		{
+			name: "duplicated lines",
+			output: "taskrun.tekton.dev/first-run created\n" +
+				"taskrun.tekton.dev/first-run created\n" +
+				"pipelinerun.tekton.dev/second-run configured\n" +
+				"taskrun.tekton.dev/first-run unchanged\n",
+			want: []TektonRun{{Name: "first-run", Kind: "taskrun"}, {Name: "second-run", Kind: "pipelinerun"}},
+		},
+		{
+			name:    "no runs",
+			output:  "task.tekton.dev/test-task created\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty output",
+			output:  "",
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := GetTektonRuns(tc.output)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("GetTektonRuns() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("GetTektonRuns() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCreateNamespace(t *testing.T) {
+	existing := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "existing"}}
+	client := fake.NewSimpleClientset(existing)
+
+	if err := CreateNamespace(client, "new"); err != nil {
+		t.Fatalf("CreateNamespace() error = %v", err)
+	}
+	if _, err := client.CoreV1().Namespaces().Get(context.TODO(), "new", metav1.GetOptions{}); err != nil {
+		t.Errorf("namespace was not created: %v", err)
+	}
+	if err := CreateNamespace(client, "existing"); err != nil {
+		t.Errorf("CreateNamespace() of an existing namespace error = %v, want nil", err)
+	}
+}
+
+func TestCreateNamespaceFailed(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "namespaces", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, "denied", errors.New("forbidden"))
+	})
+
+	err := CreateNamespace(client, "denied")
+	if !errors.Is(err, ErrNamespaceCreateFailed) {
+		t.Errorf("CreateNamespace() error = %v, want ErrNamespaceCreateFailed", err)
+	}
+	if !apierrors.IsForbidden(err) {
+		t.Errorf("CreateNamespace() error = %v, want the Forbidden API error", err)
+	}
+}
+
+func TestDeleteNamespace(t *testing.T) {
+	existing := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "existing"}}
+	client := fake.NewSimpleClientset(existing)
+
+	if err := DeleteNamespace(client, "existing"); err != nil {
+		t.Fatalf("DeleteNamespace() error = %v", err)
+	}
+	if _, err := client.CoreV1().Namespaces().Get(context.TODO(), "existing", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("namespace was not deleted: %v", err)
+	}
+	if err := DeleteNamespace(client, "missing"); err != nil {
+		t.Errorf("DeleteNamespace() of a missing namespace error = %v, want nil", err)
+	}
+}
+
+func TestDeleteNamespaceFailed(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("delete", "namespaces", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, "denied", errors.New("forbidden"))
+	})
+
+	if err := DeleteNamespace(client, "denied"); !apierrors.IsForbidden(err) {
+		t.Errorf("DeleteNamespace() error = %v, want the Forbidden API error", err)
+	}
+}
+
+func TestWaitForTektonRunCompletionReestablishesClosedWatch(t *testing.T) {
+	running := newTaskRun("test-run", "1", corev1.ConditionUnknown, v1.TaskRunReasonRunning.String())
+	succeeded := newTaskRun("test-run", "2", corev1.ConditionTrue, v1.TaskRunReasonSuccessful.String())
+
+	client := tektonfake.NewSimpleClientset()
+	var resourceVersions []string
+	client.PrependWatchReactor("taskruns", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		resourceVersions = append(resourceVersions, action.(k8stesting.WatchActionImpl).GetWatchRestrictions().ResourceVersion)
+		watcher := watch.NewFake()
+		if len(resourceVersions) == 1 {
+			// The API server closes the first watch while the run is still running
+			go func() {
+				watcher.Add(running)
+				watcher.Stop()
+			}()
+		} else {
+			go watcher.Modify(succeeded)
+		}
+		return true, watcher, nil
+	})
+
+	tektonRun := TektonRun{Name: "test-run", Kind: "taskrun"}
+	if err := WaitForTektonRunCompletionE(context.TODO(), client, tektonRun, time.Minute, string(apis.ConditionSucceeded), "default"); err != nil {
+		t.Fatalf("WaitForTektonRunCompletionE() error = %v", err)
+	}
+	if want := []string{"", "1"}; !reflect.DeepEqual(resourceVersions, want) {
+		t.Errorf("watches started from resourceVersions %q, want %q", resourceVersions, want)
+	}
+}
+
+func TestWaitForTektonRunCompletionBacksOffEmptyWatches(t *testing.T) {
+	succeeded := newTaskRun("test-run", "2", corev1.ConditionTrue, v1.TaskRunReasonSuccessful.String())
+
+	client := tektonfake.NewSimpleClientset()
+	var watches []time.Time
+	client.PrependWatchReactor("taskruns", func(k8stesting.Action) (bool, watch.Interface, error) {
+		watches = append(watches, time.Now())
+		watcher := watch.NewFake()
+		if len(watches) < 3 {
+			// The API server closes the first two watches without sending any event
+			go watcher.Stop()
+		} else {
+			go watcher.Modify(succeeded)
+		}
+		return true, watcher, nil
+	})
+
+	tektonRun := TektonRun{Name: "test-run", Kind: "taskrun"}
+	if err := WaitForTektonRunCompletionE(context.TODO(), client, tektonRun, time.Minute, string(apis.ConditionSucceeded), "default"); err != nil {
+		t.Fatalf("WaitForTektonRunCompletionE() error = %v", err)
+	}
+	if len(watches) != 3 {
+		t.Fatalf("started %d watches, want 3", len(watches))
+	}
+	if got := watches[1].Sub(watches[0]); got < rewatchBackoff {
+		t.Errorf("second watch started %v after the first, want at least %v", got, rewatchBackoff)
+	}
+	if got := watches[2].Sub(watches[1]); got < 2*rewatchBackoff {
+		t.Errorf("third watch started %v after the second, want at least %v", got, 2*rewatchBackoff)
+	}
+}
+
+// newTaskRun returns a TaskRun at the resourceVersion with the Succeeded condition in the status
+func newTaskRun(name, resourceVersion string, status corev1.ConditionStatus, reason string) *v1.TaskRun {
+	taskRun := &v1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", ResourceVersion: resourceVersion}}
+	taskRun.Status.SetCondition(&apis.Condition{Type: apis.ConditionSucceeded, Status: status, Reason: reason})
+	return taskRun
+}
+
+func TestWaitForTektonRunStartedPipelineRun(t *testing.T) {
+	// A PipelineRun reports the Running reason before any of its pods exist
+	pipelineRun := &v1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: "test-run", Namespace: "default"}}
+	pipelineRun.Status.SetCondition(&apis.Condition{Type: apis.ConditionSucceeded, Status: corev1.ConditionUnknown, Reason: v1.PipelineRunReasonRunning.String()})
+	tektonRun := TektonRun{Name: "test-run", Kind: "pipelinerun"}
+
+	tests := []struct {
+		name    string
+		pods    []runtime.Object
+		wantErr error
+	}{
+		{
+			name:    "no pods",
+			wantErr: ErrRunTimedOut,
+		},
+		{
+			name:    "pending pod",
+			pods:    []runtime.Object{newPod("test-run-task-pod", "test-run", corev1.PodPending)},
+			wantErr: ErrRunTimedOut,
+		},
+		{
+			name: "running pod",
+			pods: []runtime.Object{newPod("test-run-task-pod", "test-run", corev1.PodRunning)},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tektonClient := tektonfake.NewSimpleClientset(pipelineRun)
+			kubeClient := fake.NewSimpleClientset(tc.pods...)
+			err := WaitForTektonRunStarted(context.TODO(), tektonClient, kubeClient, tektonRun, time.Second, "default")
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("WaitForTektonRunStarted() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// newPod returns a pod of the PipelineRun in the phase
+func newPod(name, pipelineRunName string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"tekton.dev/pipelineRun": pipelineRunName}},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemanager
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	logPollInterval = 2 * time.Second
+)
+
+// GetTektonRunLogs returns the logs of every container of the Tekton TaskRun or PipelineRun pods, concatenated with a header per container.
+// A container that never started, e.g. because its image could not be pulled, or whose logs cannot be read is reported under its header
+// instead. An error is returned only if no container logs could be read at all.
+func GetTektonRunLogs(ctx context.Context, kubeClient kubernetes.Interface, tektonRun TektonRun, namespace string) (string, error) {
+	pods, err := listTektonRunPods(ctx, kubeClient, tektonRun, namespace)
+	if err != nil {
+		return "", err
+	}
+	if len(pods) == 0 {
+		return "", fmt.Errorf("no pods found for %s %s", tektonRun.Kind, tektonRun.Name)
+	}
+
+	var logs strings.Builder
+	read := 0
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			fmt.Fprintf(&logs, "=== %s/%s ===\n", pod.Name, container.Name)
+			if waiting := notStartedReason(pod, container.Name); waiting != "" {
+				fmt.Fprintf(&logs, "(not started: %s)\n", waiting)
+				continue
+			}
+			containerLogs, err := getContainerLogs(ctx, kubeClient, pod.Name, container.Name, namespace)
+			if err != nil {
+				fmt.Fprintf(&logs, "(%v)\n", err)
+				continue
+			}
+			read++
+			logs.WriteString(containerLogs)
+			if containerLogs != "" && !strings.HasSuffix(containerLogs, "\n") {
+				logs.WriteString("\n")
+			}
+		}
+	}
+	if read == 0 {
+		return "", fmt.Errorf("no container logs could be read for %s %s:\n%s", tektonRun.Kind, tektonRun.Name, logs.String())
+	}
+	return logs.String(), nil
+}
+
+// notStartedReason returns why the container of the pod has not started yet, or an empty string if it has started
+func notStartedReason(pod corev1.Pod, containerName string) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != containerName {
+			continue
+		}
+		if status.State.Waiting != nil && status.LastTerminationState.Terminated == nil {
+			return strings.TrimSpace(status.State.Waiting.Reason + " " + status.State.Waiting.Message)
+		}
+		return ""
+	}
+	// The pod has not been scheduled or has not reported the container yet
+	return fmt.Sprintf("pod is %s", pod.Status.Phase)
+}
+
+// GetContainerLogs returns the logs of the named container in the pods of the Tekton TaskRun or PipelineRun.
+// A container that never started, e.g. because its image could not be pulled, is reported with the reason it is waiting.
+func GetContainerLogs(ctx context.Context, kubeClient kubernetes.Interface, tektonRun TektonRun, containerName, namespace string) (string, error) {
+	pods, err := listTektonRunPods(ctx, kubeClient, tektonRun, namespace)
+	if err != nil {
+		return "", err
+	}
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			if container.Name != containerName {
+				continue
+			}
+			if reason := notStartedReason(pod, containerName); reason != "" {
+				return "", fmt.Errorf("container '%s' in pod %s has not started: %s", containerName, pod.Name, reason)
+			}
+			return getContainerLogs(ctx, kubeClient, pod.Name, containerName, namespace)
+		}
+	}
+	return "", fmt.Errorf("container '%s' not found in the pods of %s %s", containerName, tektonRun.Kind, tektonRun.Name)
+}
+
+// GetStepLogs returns the logs of the named step of the Tekton TaskRun, or of the step in any TaskRun of the PipelineRun
+func GetStepLogs(ctx context.Context, kubeClient kubernetes.Interface, tektonRun TektonRun, stepName, namespace string) (string, error) {
+	// Tekton names step containers after the step
+	return GetContainerLogs(ctx, kubeClient, tektonRun, "step-"+stepName, namespace)
+}
+
+// GetSidecarLogs returns the logs of the named sidecar of the Tekton TaskRun, or of the sidecar in any TaskRun of the PipelineRun
+func GetSidecarLogs(ctx context.Context, kubeClient kubernetes.Interface, tektonRun TektonRun, sidecarName, namespace string) (string, error) {
+	// Tekton names sidecar containers after the sidecar
+	return GetContainerLogs(ctx, kubeClient, tektonRun, "sidecar-"+sidecarName, namespace)
+}
+
+// ListTektonRunContainers lists the containers of the Tekton TaskRun or PipelineRun pods as pod/container with their state,
+// to show which step and sidecar logs are available
+func ListTektonRunContainers(ctx context.Context, kubeClient kubernetes.Interface, tektonRun TektonRun, namespace string) ([]string, error) {
+	pods, err := listTektonRunPods(ctx, kubeClient, tektonRun, namespace)
+	if err != nil {
+		return nil, err
+	}
+	var containers []string
+	for _, pod := range pods {
+		states := map[string]string{}
+		for _, status := range pod.Status.ContainerStatuses {
+			switch {
+			case status.State.Running != nil:
+				states[status.Name] = "running"
+			case status.State.Terminated != nil:
+				states[status.Name] = fmt.Sprintf("terminated: %s", status.State.Terminated.Reason)
+			case status.State.Waiting != nil:
+				states[status.Name] = fmt.Sprintf("waiting: %s", status.State.Waiting.Reason)
+			}
+		}
+		for _, container := range pod.Spec.Containers {
+			state, ok := states[container.Name]
+			if !ok {
+				state = "not started"
+			}
+			containers = append(containers, fmt.Sprintf("%s/%s (%s)", pod.Name, container.Name, state))
+		}
+	}
+	return containers, nil
+}
+
+// getContainerLogs returns the logs of a single container written so far
+func getContainerLogs(ctx context.Context, kubeClient kubernetes.Interface, podName, containerName, namespace string) (string, error) {
+	stream, err := kubeClient.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{Container: containerName}).Stream(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get logs of %s/%s: %v", podName, containerName, err)
+	}
+	defer stream.Close()
+	logs, err := io.ReadAll(stream)
+	if err != nil {
+		return "", fmt.Errorf("failed to read logs of %s/%s: %v", podName, containerName, err)
+	}
+	return string(logs), nil
+}
+
+// streamTektonRunLogs tails the logs of every container of the Tekton TaskRun or PipelineRun pods to t.Log until ctx is done.
+// Pods and containers that do not exist or have not started yet are picked up once they do.
+func streamTektonRunLogs(ctx context.Context, t *testing.T, kubeClient kubernetes.Interface, tektonRun TektonRun, namespace string) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	streamed := map[string]bool{}
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+	for {
+		// Pods are listed again on every tick to pick up pods created after the wait began
+		pods, err := listTektonRunPods(ctx, kubeClient, tektonRun, namespace)
+		if err == nil {
+			for _, pod := range pods {
+				for _, container := range pod.Spec.Containers {
+					key := pod.Name + "/" + container.Name
+					if streamed[key] {
+						continue
+					}
+					streamed[key] = true
+					wg.Add(1)
+					go func(podName, containerName string) {
+						defer wg.Done()
+						streamContainerLogs(ctx, t, kubeClient, podName, containerName, namespace)
+					}(pod.Name, container.Name)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// streamContainerLogs follows the logs of a single container to t.Log until the container exits or ctx is done
+func streamContainerLogs(ctx context.Context, t *testing.T, kubeClient kubernetes.Interface, podName, containerName, namespace string) {
+	for {
+		stream, err := kubeClient.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
+			Container: containerName,
+			Follow:    true,
+		}).Stream(ctx)
+		if err == nil {
+			defer stream.Close()
+			scanner := bufio.NewScanner(stream)
+			for scanner.Scan() {
+				Logf(t, "[%s/%s] %s", podName, containerName, scanner.Text())
+			}
+			return
+		}
+
+		// The container has not started yet
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(logPollInterval):
+		}
+	}
+}
+
+// listTektonRunPods lists the pods of the Tekton TaskRun, or of all child TaskRuns of the PipelineRun, by their Tekton labels
+func listTektonRunPods(ctx context.Context, kubeClient kubernetes.Interface, tektonRun TektonRun, namespace string) ([]corev1.Pod, error) {
+	label, err := tektonRunPodLabel(tektonRun)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", label, tektonRun.Name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods for %s %s: %v", tektonRun.Kind, tektonRun.Name, err)
+	}
+	return pods.Items, nil
+}
+
+// tektonRunPodLabel returns the label Tekton sets on the pods of the TaskRun, or of all child TaskRuns of the PipelineRun
+func tektonRunPodLabel(tektonRun TektonRun) (string, error) {
+	switch strings.ToLower(tektonRun.Kind) {
+	case "taskrun":
+		return "tekton.dev/taskRun", nil
+	case "pipelinerun":
+		return "tekton.dev/pipelineRun", nil
+	default:
+		return "", fmt.Errorf("unsupported Tekton Run kind: %s", tektonRun.Kind)
+	}
+}
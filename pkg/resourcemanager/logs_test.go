@@ -0,0 +1,64 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemanager
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetTektonRunLogs(t *testing.T) {
+	tektonRun := TektonRun{Name: "test-run", Kind: "taskrun"}
+	started := corev1.ContainerStatus{Name: "step-build", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}}
+	imagePullBackOff := corev1.ContainerStatus{Name: "sidecar-registry", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}}
+
+	t.Run("container not started", func(t *testing.T) {
+		client := fake.NewSimpleClientset(newTaskRunPod(started, imagePullBackOff))
+		logs, err := GetTektonRunLogs(context.TODO(), client, tektonRun, "default")
+		if err != nil {
+			t.Fatalf("GetTektonRunLogs() error = %v", err)
+		}
+		// The fake clientset returns "fake logs" for every container
+		for _, want := range []string{"=== test-run-pod/step-build ===\nfake logs", "=== test-run-pod/sidecar-registry ===\n(not started: ImagePullBackOff)"} {
+			if !strings.Contains(logs, want) {
+				t.Errorf("GetTektonRunLogs() = %q, want it to contain %q", logs, want)
+			}
+		}
+	})
+
+	t.Run("no container started", func(t *testing.T) {
+		client := fake.NewSimpleClientset(newTaskRunPod(imagePullBackOff))
+		if _, err := GetTektonRunLogs(context.TODO(), client, tektonRun, "default"); err == nil || !strings.Contains(err.Error(), "ImagePullBackOff") {
+			t.Errorf("GetTektonRunLogs() error = %v, want an error reporting ImagePullBackOff", err)
+		}
+	})
+}
+
+// newTaskRunPod returns a pod of the TaskRun test-run with a container per status
+func newTaskRunPod(statuses ...corev1.ContainerStatus) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-run-pod", Namespace: "default", Labels: map[string]string{"tekton.dev/taskRun": "test-run"}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: statuses},
+	}
+	for _, status := range statuses {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: status.Name})
+	}
+	return pod
+}
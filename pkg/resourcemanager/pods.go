@@ -0,0 +1,119 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemanager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultImagePullGracePeriod is how long a container may fail to pull its image before the wait fails, if not set in WaitOptions
+	DefaultImagePullGracePeriod = time.Minute
+)
+
+// imagePullFailureReasons are the waiting reasons of a container whose image cannot be pulled
+var imagePullFailureReasons = map[string]bool{
+	"ImagePullBackOff": true,
+	"ErrImagePull":     true,
+	"InvalidImageName": true,
+}
+
+// detectImagePullFailure polls the pods of the Tekton TaskRun or PipelineRun until ctx is done, and returns an error once a container
+// has been failing to pull its image for longer than the grace period. It returns nil when ctx is done first.
+func detectImagePullFailure(ctx context.Context, kubeClient kubernetes.Interface, tektonRun TektonRun, namespace string, gracePeriod time.Duration) error {
+	// failingSince is when each pod/container was first seen failing to pull its image
+	failingSince := map[string]time.Time{}
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+	for {
+		pods, err := listTektonRunPods(ctx, kubeClient, tektonRun, namespace)
+		if err == nil {
+			failing := map[string]time.Time{}
+			for _, pod := range pods {
+				statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+				for _, status := range statuses {
+					if status.State.Waiting == nil || !imagePullFailureReasons[status.State.Waiting.Reason] {
+						continue
+					}
+					key := pod.Name + "/" + status.Name
+					since, ok := failingSince[key]
+					if !ok {
+						since = time.Now()
+					}
+					if time.Since(since) > gracePeriod {
+						return classify(ErrRunFailed, fmt.Errorf("%s %s: container %s cannot pull image %s for more than %v (%s: %s)",
+							tektonRun.Kind, tektonRun.Name, key, status.Image, gracePeriod, status.State.Waiting.Reason, status.State.Waiting.Message))
+					}
+					failing[key] = since
+				}
+			}
+			// Containers that recovered start a new grace period if they fail again
+			failingSince = failing
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetNamespaceEvents returns the events of the namespace formatted like kubectl get events, oldest first.
+// Events explain runs that never start, e.g. scheduling failures or admission webhooks rejecting pods.
+func GetNamespaceEvents(ctx context.Context, kubeClient kubernetes.Interface, namespace string) (string, error) {
+	events, err := kubeClient.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list events in namespace %s: %v", namespace, err)
+	}
+	sort.SliceStable(events.Items, func(i, j int) bool {
+		return eventTime(events.Items[i]).Before(eventTime(events.Items[j]))
+	})
+
+	var out strings.Builder
+	w := tabwriter.NewWriter(&out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "LAST SEEN\tTYPE\tREASON\tOBJECT\tMESSAGE")
+	for _, event := range events.Items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s/%s\t%s\n", duration.HumanDuration(time.Since(eventTime(event))), event.Type, event.Reason,
+			strings.ToLower(event.InvolvedObject.Kind), event.InvolvedObject.Name, strings.TrimSpace(event.Message))
+	}
+	if err := w.Flush(); err != nil {
+		return "", fmt.Errorf("failed to format events: %v", err)
+	}
+	return out.String(), nil
+}
+
+// eventTime returns when the event was last seen, falling back to the fields set by older event producers
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	}
+	return event.CreationTimestamp.Time
+}
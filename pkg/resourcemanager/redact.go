@@ -0,0 +1,75 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemanager
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+const (
+	redactedValue = "[REDACTED]"
+)
+
+var (
+	redactMu sync.RWMutex
+	// redactPatterns match sensitive values that are removed from logged output
+	redactPatterns = []*regexp.Regexp{
+		// Service account emails
+		regexp.MustCompile(`[a-z0-9-]+@[a-z0-9-]+\.iam\.gserviceaccount\.com`),
+		// Google OAuth access tokens
+		regexp.MustCompile(`ya29\.[0-9A-Za-z_\-.]+`),
+	}
+)
+
+// AddRedactionPatterns adds regular expressions matching sensitive values (e.g. project ids) to remove from logged output
+func AddRedactionPatterns(patterns ...string) error {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid redaction pattern '%s': %v", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	redactMu.Lock()
+	defer redactMu.Unlock()
+	redactPatterns = append(redactPatterns, compiled...)
+	return nil
+}
+
+// Redact replaces all values matching the redaction patterns in s
+func Redact(s string) string {
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+	for _, re := range redactPatterns {
+		s = re.ReplaceAllString(s, redactedValue)
+	}
+	return s
+}
+
+// Logf logs the formatted message with sensitive values redacted
+func Logf(t *testing.T, format string, args ...interface{}) {
+	t.Helper()
+	t.Log(Redact(fmt.Sprintf(format, args...)))
+}
+
+// Fatalf fails the test with the formatted message with sensitive values redacted
+func Fatalf(t *testing.T, format string, args ...interface{}) {
+	t.Helper()
+	t.Fatal(Redact(fmt.Sprintf(format, args...)))
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemanager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetStepResultFromAttempt gets a step result from a specific attempt of a Tekton TaskRun configured with retries.
+// Attempts are numbered from 0: attempts before the last one are read from Status.RetriesStatus and the last attempt from Status.Steps.
+func GetStepResultFromAttempt(ctx context.Context, tektonClient versioned.Interface, tektonRun TektonRun, attempt int, stepName, resultName, namespace string) (v1.TaskRunStepResult, error) {
+	if strings.ToLower(tektonRun.Kind) != "taskrun" {
+		return v1.TaskRunStepResult{}, fmt.Errorf("unsupported Tekton Run kind for verifying step-level results: %s", tektonRun.Kind)
+	}
+	taskRun, err := tektonClient.TektonV1().TaskRuns(namespace).Get(ctx, tektonRun.Name, metav1.GetOptions{})
+	if err != nil {
+		return v1.TaskRunStepResult{}, fmt.Errorf("failed to get TaskRun: %v", err)
+	}
+
+	retries := taskRun.Status.RetriesStatus
+	var steps []v1.StepState
+	switch {
+	case attempt >= 0 && attempt < len(retries):
+		steps = retries[attempt].Steps
+	case attempt == len(retries):
+		steps = taskRun.Status.Steps
+	default:
+		return v1.TaskRunStepResult{}, fmt.Errorf("attempt %d not found, TaskRun '%s' has %d attempts", attempt, tektonRun.Name, len(retries)+1)
+	}
+
+	for _, step := range steps {
+		if step.Name != stepName {
+			continue
+		}
+		for _, result := range step.Results {
+			if result.Name == resultName {
+				return result, nil
+			}
+		}
+		return v1.TaskRunStepResult{}, fmt.Errorf("step result '%s' not found in step '%s' of attempt %d", resultName, stepName, attempt)
+	}
+	return v1.TaskRunStepResult{}, fmt.Errorf("step '%s' not found in attempt %d", stepName, attempt)
+}
+
+// GetTaskRunResults gets the Task-level results of the Tekton TaskRun
+func GetTaskRunResults(ctx context.Context, tektonClient versioned.Interface, name, namespace string) ([]v1.TaskRunResult, error) {
+	taskRun, err := tektonClient.TektonV1().TaskRuns(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TaskRun: %v", err)
+	}
+	return taskRun.Status.Results, nil
+}
+
+// GetPipelineRunResults gets the Pipeline-level results of the Tekton PipelineRun
+func GetPipelineRunResults(ctx context.Context, tektonClient versioned.Interface, name, namespace string) ([]v1.PipelineRunResult, error) {
+	pipelineRun, err := tektonClient.TektonV1().PipelineRuns(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PipelineRun: %v", err)
+	}
+	return pipelineRun.Status.Results, nil
+}
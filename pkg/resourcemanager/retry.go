@@ -0,0 +1,134 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemanager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"knative.dev/pkg/apis"
+)
+
+var (
+	// infrastructureReasons are condition reasons caused by the cluster rather than the catalog entry under test
+	infrastructureReasons = []string{
+		string(v1.TaskRunReasonImagePullFailed),
+		"PullImageFailed",
+		"ExceededNodeResources",
+	}
+
+	// infrastructureMessages are condition message fragments caused by the cluster rather than the catalog entry under test
+	infrastructureMessages = []string{
+		"ImagePullBackOff",
+		"ErrImagePull",
+		"node.kubernetes.io/not-ready",
+		"node.kubernetes.io/unreachable",
+		"NodeNotReady",
+	}
+
+	// resolutionReasons are condition reasons reported when a remote Task or Pipeline could not be resolved
+	resolutionReasons = []string{
+		string(v1.TaskRunReasonFailedResolution),
+		string(v1.PipelineRunReasonCouldntGetPipeline),
+		string(v1.PipelineRunReasonCouldntGetTask),
+	}
+
+	// transientResolutionMessages are condition message fragments marking a resolution failure as transient
+	transientResolutionMessages = []string{
+		"context deadline exceeded",
+		"timed out",
+		"connection refused",
+		"connection reset",
+		"TOOMANYREQUESTS",
+		"503 Service Unavailable",
+	}
+)
+
+// ApplyAndWaitWithRetry applies the Test YAML file and waits for the Tekton TaskRun or PipelineRun to complete with the expected condition.
+// When the run fails for infrastructure reasons (image pull backoff, node not ready, transient resolver errors) the applied resources are
+// deleted and the whole cycle is retried up to maxAttempts times. Any other failure fails the test immediately.
+func ApplyAndWaitWithRetry(ctx context.Context, t *testing.T, tektonClient versioned.Interface, testFilePath, namespace, expectedCondition string, watchTimeout time.Duration, maxAttempts int) TektonRun {
+	t.Helper()
+	for attempt := 1; ; attempt++ {
+		tektonRun, err := ApplyTestYAMLContext(ctx, testFilePath, namespace, ApplyOptions{})
+		if err != nil {
+			Fatalf(t, "%v", err)
+		}
+
+		err = WaitForTektonRunCompletionE(ctx, tektonClient, tektonRun, watchTimeout, expectedCondition, namespace)
+		if err == nil {
+			return tektonRun
+		}
+
+		cond, condErr := GetTektonRunCondition(ctx, tektonClient, tektonRun, namespace)
+		if condErr != nil {
+			Fatalf(t, "%v (failed to get run condition: %v)", err, condErr)
+		}
+		if !IsInfrastructureFailure(&cond) {
+			Fatalf(t, "%v", err)
+		}
+		if attempt >= maxAttempts {
+			Fatalf(t, "%v after %d attempts (infrastructure failure: %s)", err, attempt, formatCondition(&cond))
+		}
+
+		Logf(t, "attempt %d/%d of %s %s failed on infrastructure (%s), retrying", attempt, maxAttempts, tektonRun.Kind, tektonRun.Name, formatCondition(&cond))
+		if err := deleteTestYAML(ctx, testFilePath, namespace); err != nil {
+			Fatalf(t, "%v", err)
+		}
+	}
+}
+
+// IsInfrastructureFailure reports whether the condition of a Tekton TaskRun or PipelineRun describes a failure caused by the cluster
+// (image pull backoff, node not ready, transient resolver errors) rather than by the Task or Pipeline under test
+func IsInfrastructureFailure(cond *apis.Condition) bool {
+	if cond == nil {
+		return false
+	}
+	for _, reason := range infrastructureReasons {
+		if cond.Reason == reason {
+			return true
+		}
+	}
+	for _, msg := range infrastructureMessages {
+		if strings.Contains(cond.Message, msg) {
+			return true
+		}
+	}
+	for _, reason := range resolutionReasons {
+		if cond.Reason != reason {
+			continue
+		}
+		for _, msg := range transientResolutionMessages {
+			if strings.Contains(cond.Message, msg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deleteTestYAML deletes the resources defined in the Test YAML file and waits for them to be removed
+func deleteTestYAML(ctx context.Context, testFilePath, namespace string) error {
+	output, err := Runner.Run(ctx, "kubectl", "delete", "-f", testFilePath, "-n", namespace, "--ignore-not-found", "--wait")
+	if err != nil {
+		return fmt.Errorf("failed to delete Test YAML file: %v\n%s", err, output)
+	}
+	return nil
+}
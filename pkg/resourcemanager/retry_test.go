@@ -0,0 +1,63 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemanager
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
+)
+
+func TestIsInfrastructureFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		reason  string
+		message string
+		want    bool
+	}{
+		{name: "TaskRunImagePullFailed reason", reason: "TaskRunImagePullFailed", want: true},
+		{name: "PullImageFailed reason", reason: "PullImageFailed", want: true},
+		{name: "ExceededNodeResources reason", reason: "ExceededNodeResources", want: true},
+		{name: "ImagePullBackOff message", reason: "Failed", message: `container "step-build" is waiting: ImagePullBackOff`, want: true},
+		{name: "ErrImagePull message", reason: "Failed", message: `container "step-build" is waiting: ErrImagePull`, want: true},
+		{name: "not-ready taint message", reason: "Failed", message: "node had taint {node.kubernetes.io/not-ready: }", want: true},
+		{name: "unreachable taint message", reason: "Failed", message: "node had taint {node.kubernetes.io/unreachable: }", want: true},
+		{name: "NodeNotReady message", reason: "Failed", message: "pod evicted: NodeNotReady", want: true},
+		{name: "FailedResolution with deadline exceeded", reason: "TaskRunResolutionFailed", message: "error requesting remote resource: context deadline exceeded", want: true},
+		{name: "FailedResolution with timeout", reason: "TaskRunResolutionFailed", message: "resolution timed out", want: true},
+		{name: "FailedResolution with connection refused", reason: "TaskRunResolutionFailed", message: "dial tcp: connection refused", want: true},
+		{name: "FailedResolution with connection reset", reason: "TaskRunResolutionFailed", message: "read: connection reset by peer", want: true},
+		{name: "FailedResolution with rate limit", reason: "TaskRunResolutionFailed", message: "TOOMANYREQUESTS: rate limit exceeded", want: true},
+		{name: "FailedResolution with service unavailable", reason: "TaskRunResolutionFailed", message: "503 Service Unavailable", want: true},
+		{name: "CouldntGetPipeline with timeout", reason: "CouldntGetPipeline", message: "resolution timed out", want: true},
+		{name: "CouldntGetTask with timeout", reason: "CouldntGetTask", message: "resolution timed out", want: true},
+		{name: "FailedResolution not found", reason: "TaskRunResolutionFailed", message: "bundle does not contain task \"build\""},
+		{name: "transient message without resolution reason", reason: "Failed", message: "connection refused"},
+		{name: "step failure", reason: "Failed", message: `"step-build" exited with code 1`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cond := &apis.Condition{Type: apis.ConditionSucceeded, Status: corev1.ConditionFalse, Reason: tc.reason, Message: tc.message}
+			if got := IsInfrastructureFailure(cond); got != tc.want {
+				t.Errorf("IsInfrastructureFailure(%s: %s) = %v, want %v", tc.reason, tc.message, got, tc.want)
+			}
+		})
+	}
+
+	if IsInfrastructureFailure(nil) {
+		t.Errorf("IsInfrastructureFailure(nil) = true, want false")
+	}
+}
@@ -0,0 +1,363 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemanager
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// tektonKinds are the kinds of the tekton.dev API group the Tekton YAML and Test YAML files may contain
+var tektonKinds = map[string]bool{
+	"Task":        true,
+	"Pipeline":    true,
+	"StepAction":  true,
+	"TaskRun":     true,
+	"PipelineRun": true,
+	"CustomRun":   true,
+	"Run":         true,
+}
+
+// ValidateTektonYAML checks that the YAML file parses and that every document has a kind and a metadata.name, and that
+// documents of the tekton.dev API group have a recognized Tekton kind, so malformed fixtures fail fast with the offending
+// document instead of deep inside kubectl apply. Other kinds, e.g. a Role or Service the test needs, are not restricted.
+// The items of a List are checked like documents.
+func ValidateTektonYAML(filePath string) error {
+	docs, err := readYAMLDocuments(filePath)
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return classify(ErrInvalidYAML, fmt.Errorf("%s contains no YAML documents", filePath))
+	}
+
+	var problems []string
+	for i, doc := range docs {
+		problems = append(problems, validateTektonObject(doc.Content[0], fmt.Sprintf("document %d", i+1))...)
+	}
+	if len(problems) > 0 {
+		return classify(ErrInvalidYAML, fmt.Errorf("invalid Tekton YAML file %s:\n  %s", filePath, strings.Join(problems, "\n  ")))
+	}
+	return nil
+}
+
+// validateTektonObject returns the problems of a single object of a Tekton YAML file, described by the label
+func validateTektonObject(node *yaml.Node, label string) []string {
+	if node.Kind != yaml.MappingNode {
+		return []string{fmt.Sprintf("%s (line %d) is not a mapping", label, node.Line)}
+	}
+	kind := mappingValue(node, "kind")
+	if kind == nil || kind.Value == "" {
+		return []string{fmt.Sprintf("%s (line %d) has no kind", label, node.Line)}
+	}
+
+	var problems []string
+	if kind.Value == "List" {
+		items := mappingValue(node, "items")
+		if items == nil || items.Kind != yaml.SequenceNode {
+			return []string{fmt.Sprintf("%s (line %d) is a List without items", label, node.Line)}
+		}
+		for i, item := range items.Content {
+			problems = append(problems, validateTektonObject(item, fmt.Sprintf("%s item %d", label, i+1))...)
+		}
+		return problems
+	}
+	if apiVersion := mappingValue(node, "apiVersion"); apiVersion != nil && strings.HasPrefix(apiVersion.Value, "tekton.dev/") && !tektonKinds[kind.Value] {
+		problems = append(problems, fmt.Sprintf("%s (line %d) has unrecognized Tekton kind %q", label, kind.Line, kind.Value))
+	}
+	if name := mappingValue(mappingValue(node, "metadata"), "name"); name == nil || name.Value == "" {
+		problems = append(problems, fmt.Sprintf("%s (line %d) has no metadata.name", label, node.Line))
+	}
+	return problems
+}
+
+// CopyTestYAML copies the YAML file to a directory removed when the test completes and returns the path of the copy.
+// SetParams, AddStepEnv and AddWorkspaceSecret rewrite the file they are given, so edit a copy of a checked-in fixture
+// to keep the source tree unchanged and table-driven cases calling t.Parallel from racing on the same file.
+func CopyTestYAML(t *testing.T, filePath string) string {
+	t.Helper()
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		Fatalf(t, "failed to read YAML file: %v", err)
+	}
+	copyPath := filepath.Join(t.TempDir(), filepath.Base(filePath))
+	if err := os.WriteFile(copyPath, data, 0644); err != nil {
+		Fatalf(t, "failed to copy YAML file: %v", err)
+	}
+	return copyPath
+}
+
+// SetParams writes the params to spec.params of every TaskRun and PipelineRun in the Test YAML file, overwriting
+// the value of params already set with the same name. Key order and comments of the file are preserved.
+// The file is rewritten in place, see CopyTestYAML.
+func SetParams(filePath string, params map[string]string) error {
+	docs, err := readYAMLDocuments(filePath)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	found := false
+	for _, doc := range docs {
+		root := doc.Content[0]
+		if kind := mappingValue(root, "kind"); kind == nil || (kind.Value != "TaskRun" && kind.Value != "PipelineRun") {
+			continue
+		}
+		found = true
+		paramList := ensureMappingValue(ensureMappingValue(root, "spec", yaml.MappingNode), "params", yaml.SequenceNode)
+		for _, name := range names {
+			setParam(paramList, name, params[name])
+		}
+	}
+	if !found {
+		return fmt.Errorf("no TaskRun or PipelineRun found in %s", filePath)
+	}
+
+	return writeYAMLDocuments(filePath, docs)
+}
+
+// setParam sets the value of the named param in the spec.params sequence, appending the param if it is not set yet
+func setParam(paramList *yaml.Node, name, value string) {
+	valueNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	for _, param := range paramList.Content {
+		if paramName := mappingValue(param, "name"); paramName != nil && paramName.Value == name {
+			setMappingValue(param, "value", valueNode)
+			return
+		}
+	}
+	param := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	setMappingValue(param, "name", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name})
+	setMappingValue(param, "value", valueNode)
+	paramList.Content = append(paramList.Content, param)
+}
+
+// readYAMLDocuments parses every non-empty document of the YAML file into a node tree
+func readYAMLDocuments(filePath string) ([]*yaml.Node, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read YAML file: %v", err)
+	}
+	var docs []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, classify(ErrInvalidYAML, fmt.Errorf("failed to parse YAML file %s: %v", filePath, err))
+		}
+		if len(doc.Content) > 0 {
+			docs = append(docs, &doc)
+		}
+	}
+	return docs, nil
+}
+
+// writeYAMLDocuments writes the node trees to the YAML file as separate documents
+func writeYAMLDocuments(filePath string, docs []*yaml.Node) error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return fmt.Errorf("failed to encode YAML: %v", err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode YAML: %v", err)
+	}
+	if err := os.WriteFile(filePath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write YAML file: %v", err)
+	}
+	return nil
+}
+
+// mappingValue returns the value of the key in the mapping node, or nil if the node is not a mapping or has no such key
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue sets the value of the key in the mapping node, appending the key if it is not present
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// ensureMappingValue returns the value of the key in the mapping node, adding an empty node of the given kind if it is not
+// present or is null
+func ensureMappingValue(node *yaml.Node, key string, kind yaml.Kind) *yaml.Node {
+	if value := mappingValue(node, key); value != nil && value.Kind == kind {
+		return value
+	}
+	value := &yaml.Node{Kind: kind}
+	switch kind {
+	case yaml.MappingNode:
+		value.Tag = "!!map"
+	case yaml.SequenceNode:
+		value.Tag = "!!seq"
+	}
+	setMappingValue(node, key, value)
+	return value
+}
+
+// workspaceBindingKeys are the keys of a workspace binding that select its volume source
+var workspaceBindingKeys = []string{"emptyDir", "persistentVolumeClaim", "volumeClaimTemplate", "configMap", "secret", "csi", "projected"}
+
+// AddStepEnv sets the environment variables on the named step wherever it is defined in the YAML file: in a Task, or in the
+// inline taskSpec of a TaskRun, Pipeline or PipelineRun. Variables already set with the same name are overwritten.
+// The file is rewritten in place, see CopyTestYAML.
+func AddStepEnv(filePath, stepName string, env map[string]string) error {
+	docs, err := readYAMLDocuments(filePath)
+	if err != nil {
+		return err
+	}
+
+	var steps []*yaml.Node
+	for _, doc := range docs {
+		findSteps(doc.Content[0], stepName, &steps)
+	}
+	if len(steps) == 0 {
+		return fmt.Errorf("step '%s' not found in %s", stepName, filePath)
+	}
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, step := range steps {
+		envList := ensureMappingValue(step, "env", yaml.SequenceNode)
+		for _, name := range names {
+			setEnv(envList, name, env[name])
+		}
+	}
+
+	return writeYAMLDocuments(filePath, docs)
+}
+
+// setEnv sets the value of the named variable in the env sequence of a step, appending the variable if it is not set yet.
+// A valueFrom source of the variable is removed, the API server rejects variables with both value and valueFrom.
+func setEnv(envList *yaml.Node, name, value string) {
+	valueNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	for _, envVar := range envList.Content {
+		if envName := mappingValue(envVar, "name"); envName != nil && envName.Value == name {
+			removeMappingKey(envVar, "valueFrom")
+			setMappingValue(envVar, "value", valueNode)
+			return
+		}
+	}
+	envVar := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	setMappingValue(envVar, "name", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name})
+	setMappingValue(envVar, "value", valueNode)
+	envList.Content = append(envList.Content, envVar)
+}
+
+// AddWorkspaceSecret binds the named workspace of every TaskRun and PipelineRun in the Test YAML file to the secret,
+// replacing any other volume source bound to it, so tests can mount credentials into the run.
+// The file is rewritten in place, see CopyTestYAML.
+func AddWorkspaceSecret(filePath, workspaceName, secretName string) error {
+	docs, err := readYAMLDocuments(filePath)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, doc := range docs {
+		root := doc.Content[0]
+		if kind := mappingValue(root, "kind"); kind == nil || (kind.Value != "TaskRun" && kind.Value != "PipelineRun") {
+			continue
+		}
+		found = true
+		workspaces := ensureMappingValue(ensureMappingValue(root, "spec", yaml.MappingNode), "workspaces", yaml.SequenceNode)
+
+		var workspace *yaml.Node
+		for _, ws := range workspaces.Content {
+			if name := mappingValue(ws, "name"); name != nil && name.Value == workspaceName {
+				workspace = ws
+				break
+			}
+		}
+		if workspace == nil {
+			workspace = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(workspace, "name", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: workspaceName})
+			workspaces.Content = append(workspaces.Content, workspace)
+		}
+		for _, key := range workspaceBindingKeys {
+			removeMappingKey(workspace, key)
+		}
+		secret := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		setMappingValue(secret, "secretName", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: secretName})
+		setMappingValue(workspace, "secret", secret)
+	}
+	if !found {
+		return fmt.Errorf("no TaskRun or PipelineRun found in %s", filePath)
+	}
+
+	return writeYAMLDocuments(filePath, docs)
+}
+
+// findSteps collects the steps with the given name from every steps sequence below the node
+func findSteps(node *yaml.Node, stepName string, steps *[]*yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		if stepList := mappingValue(node, "steps"); stepList != nil && stepList.Kind == yaml.SequenceNode {
+			for _, step := range stepList.Content {
+				if name := mappingValue(step, "name"); name != nil && name.Value == stepName {
+					*steps = append(*steps, step)
+				}
+			}
+		}
+	}
+	for _, child := range node.Content {
+		findSteps(child, stepName, steps)
+	}
+}
+
+// removeMappingKey removes the key and its value from the mapping node if present
+func removeMappingKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
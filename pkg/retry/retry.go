@@ -0,0 +1,74 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package retry provides utility functions for retrying operations that fail transiently.
+package retry
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// transientMessages are error message fragments of transient API throttling, server and network failures
+var transientMessages = []string{
+	"TooManyRequests",
+	"too many requests",
+	"Internal error occurred",
+	"500 Internal Server Error",
+	"502 Bad Gateway",
+	"503 Service Unavailable",
+	"504 Gateway Timeout",
+	"the server is currently unable to handle the request",
+	"etcdserver: request timed out",
+	"connection refused",
+	"connection reset by peer",
+	"i/o timeout",
+	"TLS handshake timeout",
+}
+
+// Do calls fn until it succeeds, fails with an error that is not transient, or has been called attempts times.
+// The wait between calls starts at backoff and doubles after each attempt. It returns the last error from fn,
+// or the context error if ctx is done while waiting.
+func Do(ctx context.Context, attempts int, backoff time.Duration, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || !IsTransient(err) || attempt >= attempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// IsTransient reports whether the error describes a transient failure (API throttling, server errors, network errors)
+// that is likely to succeed when retried
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	for _, transient := range transientMessages {
+		if strings.Contains(msg, transient) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	errTransient = errors.New("503 Service Unavailable")
+	errPermanent = errors.New("tasks.tekton.dev \"build\" is invalid")
+)
+
+func TestDoSucceeds(t *testing.T) {
+	calls := 0
+	err := Do(context.TODO(), 3, time.Millisecond, func() error {
+		calls++
+		if calls < 2 {
+			return errTransient
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("Do() = %v after %d calls, want nil after 2 calls", err, calls)
+	}
+}
+
+func TestDoStopsOnNonTransientError(t *testing.T) {
+	calls := 0
+	err := Do(context.TODO(), 3, time.Millisecond, func() error {
+		calls++
+		return errPermanent
+	})
+	if !errors.Is(err, errPermanent) || calls != 1 {
+		t.Errorf("Do() = %v after %d calls, want %v after 1 call", err, calls, errPermanent)
+	}
+}
+
+func TestDoStopsAfterAttempts(t *testing.T) {
+	calls := 0
+	err := Do(context.TODO(), 3, time.Millisecond, func() error {
+		calls++
+		return errTransient
+	})
+	if !errors.Is(err, errTransient) || calls != 3 {
+		t.Errorf("Do() = %v after %d calls, want %v after 3 calls", err, calls, errTransient)
+	}
+}
+
+func TestDoDoublesBackoff(t *testing.T) {
+	const backoff = 20 * time.Millisecond
+	var calls []time.Time
+	_ = Do(context.TODO(), 3, backoff, func() error {
+		calls = append(calls, time.Now())
+		return errTransient
+	})
+	if len(calls) != 3 {
+		t.Fatalf("Do() made %d calls, want 3", len(calls))
+	}
+	if wait := calls[1].Sub(calls[0]); wait < backoff {
+		t.Errorf("first wait = %v, want at least %v", wait, backoff)
+	}
+	if wait := calls[2].Sub(calls[1]); wait < 2*backoff {
+		t.Errorf("second wait = %v, want at least %v", wait, 2*backoff)
+	}
+}
+
+func TestDoReturnsContextErrorWhileWaiting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	start := time.Now()
+	err := Do(ctx, 3, time.Hour, func() error {
+		calls++
+		cancel()
+		return errTransient
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("Do() = %v after %d calls, want %v after 1 call", err, calls, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > time.Minute {
+		t.Errorf("Do() returned after %v, want it to stop waiting once the context is cancelled", elapsed)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: nil, want: false},
+		{err: errTransient, want: true},
+		{err: errors.New("Error from server (TooManyRequests): the server has received too many requests"), want: true},
+		{err: errors.New("dial tcp 10.0.0.1:443: connect: connection refused"), want: true},
+		{err: errors.New("net/http: TLS handshake timeout"), want: true},
+		{err: errPermanent, want: false},
+		{err: errors.New("error: the path \"test.yaml\" does not exist"), want: false},
+	}
+	for _, tc := range tests {
+		if got := IsTransient(tc.err); got != tc.want {
+			t.Errorf("IsTransient(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package setup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gcb-catalog-testing-bot/catalog-infra/pkg/resourcemanager"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/apis"
+)
+
+// RunTestOptions configures a test run by RunTest
+type RunTestOptions struct {
+	// TektonYAMLPath is the Tekton YAML (e.g. the StepAction under test) applied to the namespace before the test run. Optional.
+	TektonYAMLPath string
+	// TestYAMLPath is the Test YAML creating the TaskRun or PipelineRun
+	TestYAMLPath string
+	// ExpectedCondition is the condition the run must complete with. Empty means Succeeded.
+	ExpectedCondition string
+	// Timeout is the time the run may take to complete. Zero means resourcemanager.DefaultWatchTimeout.
+	Timeout time.Duration
+	// Assertions are run in order once the run completed with the expected condition
+	Assertions []func(t *testing.T, run TestRun)
+}
+
+// TestRun is the completed run of RunTest, passed to its assertions
+type TestRun struct {
+	KubeClient   *kubernetes.Clientset
+	TektonClient *versioned.Clientset
+	TektonRun    resourcemanager.TektonRun
+	Namespace    string
+}
+
+// RunTest runs a whole catalog test: it initializes the clients, sets up a namespace with the Tekton YAML, applies the Test YAML,
+// waits for the run to complete with the expected condition and runs the assertions on it. The namespace is torn down afterwards.
+// Each call runs in its own namespace, so tests using RunTest can call t.Parallel, with the same caveat about
+// resourcemanager.Runner as SetupTest.
+func RunTest(t *testing.T, opts RunTestOptions) {
+	t.Helper()
+	kubeClient, tektonClient := InitK8sClients(t)
+
+	var namespace string
+	var cleanup func()
+	if opts.TektonYAMLPath == "" {
+		namespace, cleanup = setupTest(t, kubeClient, nil)
+	} else {
+		namespace, cleanup = SetupTest(t, kubeClient, opts.TektonYAMLPath)
+	}
+	defer cleanup()
+
+	expectedCondition := opts.ExpectedCondition
+	if expectedCondition == "" {
+		expectedCondition = string(apis.ConditionSucceeded)
+	}
+
+	tektonRun := resourcemanager.ApplyTestYAML(t, opts.TestYAMLPath, namespace)
+	resourcemanager.WaitForTektonRunCompletionWithOptions(t, tektonClient, tektonRun, opts.Timeout, expectedCondition, namespace,
+		resourcemanager.WaitOptions{KubeClient: kubeClient})
+
+	run := TestRun{
+		KubeClient:   kubeClient,
+		TektonClient: tektonClient,
+		TektonRun:    tektonRun,
+		Namespace:    namespace,
+	}
+	for _, assertion := range opts.Assertions {
+		assertion(t, run)
+	}
+}
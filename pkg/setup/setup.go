@@ -16,48 +16,147 @@
 package setup
 
 import (
+	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/gcb-catalog-testing-bot/catalog-infra/pkg/resourcemanager"
 	"github.com/google/uuid"
 	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 )
 
+const (
+	// keepOnFailureEnv is the environment variable that, when true, keeps the namespace of a failed test for debugging
+	keepOnFailureEnv = "CATALOG_KEEP_ON_FAILURE"
+	// keepNamespaceEnv is the environment variable that, when true, keeps the namespace of every test like -keepNamespace
+	keepNamespaceEnv = "CATALOG_KEEP_NAMESPACE"
+
+	// namespacePrefix and namespaceIDLength define the generated namespace names, e.g. it-1a2b3c4d5e6f
+	namespacePrefix   = "it-"
+	namespaceIDLength = 12
+)
+
+// keepNamespace keeps the namespace of every test, passed or failed, to inspect its resources by hand.
+// The flag is only defined in test binaries importing this package, so pass it per package, e.g. go test ./tests/mytask -args -keepNamespace.
+// With go test ./... the other test binaries exit with "flag provided but not defined", so set CATALOG_KEEP_NAMESPACE=true instead.
+var keepNamespace = flag.Bool("keepNamespace", false, "keep the test namespaces instead of deleting them after each test")
+
 // SetupTest creates a temporary namespace for testing and returns the namespace name and a cleanup function.
-func SetupTest(t *testing.T, client *kubernetes.Clientset, tektonYAMLPath string) (string, func()) {
+// Every call gets its own uniquely named namespace, so it is safe to use from tests calling t.Parallel, as long as no test in the
+// binary replaces the package-wide resourcemanager.Runner meanwhile, e.g. with resourcemanager.EnableDryRun.
+func SetupTest(t *testing.T, client kubernetes.Interface, tektonYAMLPath string) (string, func()) {
+	t.Helper()
+	return setupTest(t, client, []string{tektonYAMLPath})
+}
+
+// SetupTestDir creates a temporary namespace for testing, applies every *.yaml file in the directory to it
+// and returns the namespace name and a cleanup function.
+func SetupTestDir(t *testing.T, client kubernetes.Interface, dir string) (string, func()) {
 	t.Helper()
-	t.Log("setting up tests ...")
+	tektonYAMLPaths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		resourcemanager.Fatalf(t, "failed to list Tekton YAML files in %s: %v", dir, err)
+	}
+	if len(tektonYAMLPaths) == 0 {
+		resourcemanager.Fatalf(t, "no Tekton YAML files found in %s", dir)
+	}
+	return setupTest(t, client, tektonYAMLPaths)
+}
+
+// setupTest creates a temporary namespace for testing, applies the Tekton YAML files to it
+// and returns the namespace name and a cleanup function.
+func setupTest(t *testing.T, client kubernetes.Interface, tektonYAMLPaths []string) (string, func()) {
+	t.Helper()
+	resourcemanager.Logf(t, "setting up tests ...")
+
+	// Validate the Tekton YAML files before creating anything in the cluster
+	for _, tektonYAMLPath := range tektonYAMLPaths {
+		if err := resourcemanager.ValidateTektonYAML(tektonYAMLPath); err != nil {
+			resourcemanager.Fatalf(t, "%v", err)
+		}
+	}
 
 	// Create a temporary namespace for testing
-	namespace := uuid.New().String()
-	if err := resourcemanager.CreateNamespace(namespace); err != nil {
-		t.Fatalf("failed to create namespace: %v", err)
+	namespace := newNamespaceName()
+	dryRun := resourcemanager.DryRunEnabled()
+	if dryRun {
+		resourcemanager.Logf(t, "[dry-run] create namespace %s", namespace)
+	} else if err := resourcemanager.CreateNamespace(client, namespace); err != nil {
+		resourcemanager.Fatalf(t, "failed to create namespace: %v", err)
 	}
-	t.Logf("using namespace: %s", namespace)
+	resourcemanager.Logf(t, "using namespace: %s", namespace)
 
 	// Cleanup function
 	cleanup := func() {
 		t.Helper()
-		t.Log("tearing down tests...")
-		if err := resourcemanager.DeleteNamespace(namespace); err != nil {
-			t.Fatalf("failed to delete namespace: %v", err)
+		if keepNamespaces() {
+			resourcemanager.Logf(t, "keeping namespace %s (-keepNamespace or %s is set)", namespace, keepNamespaceEnv)
+			return
+		}
+		if t.Failed() && keepOnFailure() {
+			resourcemanager.Logf(t, "test failed, keeping namespace %s for debugging (%s is set)", namespace, keepOnFailureEnv)
+			return
+		}
+		if dryRun {
+			resourcemanager.Logf(t, "[dry-run] delete namespace %s", namespace)
+			return
+		}
+		resourcemanager.Logf(t, "tearing down tests...")
+		if err := TeardownTest(client, namespace); err != nil {
+			resourcemanager.Fatalf(t, "%v", err)
 		}
 	}
 
 	// Apply StepAction YAML
-	if err := resourcemanager.ApplyStepActionYAML(tektonYAMLPath, namespace); err != nil {
-		t.Fatalf("failed to apply Tekton YAML: %v", err)
+	for _, tektonYAMLPath := range tektonYAMLPaths {
+		if err := resourcemanager.ApplyStepActionYAML(tektonYAMLPath, namespace); err != nil {
+			resourcemanager.Fatalf(t, "failed to apply Tekton YAML: %v", err)
+		}
 	}
 
 	return namespace, cleanup
 }
 
-// InitK8sClients initializes a k8s client and a Tekton client.
+// TeardownTest deletes the temporary namespace created by SetupTest and all resources in it.
+// It is what the cleanup function returned by SetupTest calls, and can be called directly for explicit teardown ordering.
+func TeardownTest(client kubernetes.Interface, namespace string) error {
+	// DeleteNamespace already describes the failure and wraps the API error for the apierrors helpers
+	return resourcemanager.DeleteNamespace(client, namespace)
+}
+
+// newNamespaceName generates a short, DNS-safe namespace name, leaving room under the 63 character
+// label limit for the suffixes Tekton appends to the names of the resources it creates
+func newNamespaceName() string {
+	id := strings.ReplaceAll(uuid.New().String(), "-", "")
+	return namespacePrefix + id[:namespaceIDLength]
+}
+
+// keepNamespaces reports whether the namespace of every test should be kept, set by -keepNamespace or CATALOG_KEEP_NAMESPACE
+func keepNamespaces() bool {
+	if *keepNamespace {
+		return true
+	}
+	keep, err := strconv.ParseBool(os.Getenv(keepNamespaceEnv))
+	return err == nil && keep
+}
+
+// keepOnFailure reports whether the namespace of a failed test should be kept for debugging
+func keepOnFailure() bool {
+	keep, err := strconv.ParseBool(os.Getenv(keepOnFailureEnv))
+	return err == nil && keep
+}
+
+// InitK8sClients initializes a k8s client and a Tekton client from the KUBECONFIG environment variable, or ~/.kube/config if unset.
+// The clients are created once per kubeconfig path and reused by later calls in the same test binary, including parallel tests.
 func InitK8sClients(t *testing.T) (*kubernetes.Clientset, *versioned.Clientset) {
 	t.Helper()
 	kubeConfig := os.Getenv("KUBECONFIG")
@@ -66,22 +165,92 @@ func InitK8sClients(t *testing.T) (*kubernetes.Clientset, *versioned.Clientset)
 		kubeConfig = filepath.Join(homedir.HomeDir(), ".kube", "config")
 	}
 
-	t.Logf("using kubeconfig: %s", kubeConfig)
+	return InitK8sClientsWithConfig(t, kubeConfig)
+}
+
+// InitK8sClientsWithConfig initializes a k8s client and a Tekton client from the kubeconfig file,
+// or from the in-cluster config if kubeConfig is empty.
+// The clients are created once per kubeconfig path and reused by later calls in the same test binary.
+func InitK8sClientsWithConfig(t *testing.T, kubeConfig string) (*kubernetes.Clientset, *versioned.Clientset) {
+	t.Helper()
+	// A dry run does not shell out to kubectl
+	if !resourcemanager.DryRunEnabled() {
+		if err := resourcemanager.CheckDependencies(); err != nil {
+			resourcemanager.Fatalf(t, "%v, please install them before running the tests", err)
+		}
+	}
+
+	if kubeConfig == "" {
+		resourcemanager.Logf(t, "using in-cluster config")
+	} else {
+		resourcemanager.Logf(t, "using kubeconfig: %s", kubeConfig)
+	}
 
-	config, err := clientcmd.BuildConfigFromFlags("", kubeConfig)
+	clients, err := getK8sClients(kubeConfig)
+	if err != nil {
+		resourcemanager.Fatalf(t, "%v", err)
+	}
+	t.Cleanup(func() {
+		CloseK8sClients(clients.k8sClientset, clients.tektonClient)
+	})
+
+	return clients.k8sClientset, clients.tektonClient
+}
+
+// CloseK8sClients closes the idle connections held by the clients so they do not pile up in long test binaries.
+// The clients stay usable and reconnect on their next request, so closing clients shared with other tests is safe.
+// InitK8sClients registers it to run when the test completes.
+func CloseK8sClients(k8sClientset *kubernetes.Clientset, tektonClient *versioned.Clientset) {
+	// The API groups of a clientset share a single HTTP client, so closing one group's connections closes them all
+	for _, restClient := range []rest.Interface{k8sClientset.CoreV1().RESTClient(), tektonClient.TektonV1().RESTClient()} {
+		if client, ok := restClient.(*rest.RESTClient); ok && client.Client != nil {
+			client.Client.CloseIdleConnections()
+		}
+	}
+}
+
+// k8sClients holds the clients created from a kubeconfig
+type k8sClients struct {
+	k8sClientset *kubernetes.Clientset
+	tektonClient *versioned.Clientset
+}
+
+var (
+	clientsMu sync.Mutex
+	// clientsCache holds the clients created so far, keyed by kubeconfig path ("" for the in-cluster config)
+	clientsCache = map[string]k8sClients{}
+)
+
+// getK8sClients returns the cached clients for the kubeconfig path, creating them on first use
+func getK8sClients(kubeConfig string) (k8sClients, error) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if clients, ok := clientsCache[kubeConfig]; ok {
+		return clients, nil
+	}
+
+	var config *rest.Config
+	var err error
+	if kubeConfig == "" {
+		config, err = rest.InClusterConfig()
+	} else {
+		config, err = clientcmd.BuildConfigFromFlags("", kubeConfig)
+	}
 	if err != nil {
-		t.Fatalf("failed to create k8s config: %v", err)
+		return k8sClients{}, fmt.Errorf("failed to create k8s config: %v", err)
 	}
 
 	k8sClientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		t.Fatalf("failed to create k8s client: %v", err)
+		return k8sClients{}, fmt.Errorf("failed to create k8s client: %v", err)
 	}
 
 	tektonClient, err := versioned.NewForConfig(config)
 	if err != nil {
-		t.Fatalf("failed to create Tekton client: %v", err)
+		return k8sClients{}, fmt.Errorf("failed to create Tekton client: %v", err)
 	}
 
-	return k8sClientset, tektonClient
+	clients := k8sClients{k8sClientset: k8sClientset, tektonClient: tektonClient}
+	clientsCache[kubeConfig] = clients
+	return clients, nil
 }
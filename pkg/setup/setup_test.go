@@ -0,0 +1,128 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package setup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gcb-catalog-testing-bot/catalog-infra/pkg/resourcemanager"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// maxNamespaceNameLength leaves room for the suffixes Tekton appends to the names of the resources it creates
+const maxNamespaceNameLength = 20
+
+func TestNewNamespaceName(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		namespace := newNamespaceName()
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			t.Errorf("newNamespaceName() = %s is not a valid RFC 1123 label: %s", namespace, strings.Join(errs, ", "))
+		}
+		if len(namespace) > maxNamespaceNameLength {
+			t.Errorf("newNamespaceName() = %s is %d characters long, want at most %d", namespace, len(namespace), maxNamespaceNameLength)
+		}
+		if seen[namespace] {
+			t.Errorf("newNamespaceName() = %s was generated twice", namespace)
+		}
+		seen[namespace] = true
+	}
+}
+
+// fakeRunner is a resourcemanager.ExecRunner recording the namespaces kubectl apply is run against
+type fakeRunner struct {
+	mu                sync.Mutex
+	appliedNamespaces []string
+}
+
+// Run records the namespace of a kubectl apply and returns its output for a StepAction
+func (r *fakeRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := 1; i+1 < len(args) && args[0] == "apply"; i++ {
+		if args[i] == "-n" {
+			r.appliedNamespaces = append(r.appliedNamespaces, args[i+1])
+		}
+	}
+	return []byte("stepaction.tekton.dev/test-step-action created\n"), nil
+}
+
+// RunWithInput runs the command like Run, ignoring the input
+func (r *fakeRunner) RunWithInput(ctx context.Context, input []byte, name string, args ...string) ([]byte, error) {
+	return r.Run(ctx, name, args...)
+}
+
+func TestSetupTestParallel(t *testing.T) {
+	t.Setenv(keepNamespaceEnv, "")
+	t.Setenv(keepOnFailureEnv, "")
+	runner := &fakeRunner{}
+	previous := resourcemanager.Runner
+	resourcemanager.Runner = runner
+	t.Cleanup(func() {
+		resourcemanager.Runner = previous
+	})
+
+	tektonYAMLPath := filepath.Join(t.TempDir(), "step-action.yaml")
+	tektonYAML := "apiVersion: tekton.dev/v1alpha1\nkind: StepAction\nmetadata:\n  name: test-step-action\n"
+	if err := os.WriteFile(tektonYAMLPath, []byte(tektonYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	client := fake.NewSimpleClientset()
+
+	var mu sync.Mutex
+	var namespaces []string
+	t.Run("group", func(t *testing.T) {
+		for _, name := range []string{"first", "second"} {
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				namespace, cleanup := SetupTest(t, client, tektonYAMLPath)
+				defer cleanup()
+				if _, err := client.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{}); err != nil {
+					t.Errorf("namespace %s was not created: %v", namespace, err)
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				namespaces = append(namespaces, namespace)
+			})
+		}
+	})
+
+	if len(namespaces) != 2 || namespaces[0] == namespaces[1] {
+		t.Fatalf("parallel tests got namespaces %v, want two distinct namespaces", namespaces)
+	}
+	for _, namespace := range namespaces {
+		applied := false
+		for _, appliedNamespace := range runner.appliedNamespaces {
+			applied = applied || appliedNamespace == namespace
+		}
+		if !applied {
+			t.Errorf("Tekton YAML was not applied to namespace %s, applied to %v", namespace, runner.appliedNamespaces)
+		}
+	}
+	remaining, err := client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining.Items) != 0 {
+		t.Errorf("%d namespaces were not deleted by the cleanup", len(remaining.Items))
+	}
+}